@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"github.com/katenester/doc/internal/app"
+	"github.com/katenester/doc/internal/service"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// Проверка хранилища: отсутствующие и поврежденные файлы документов и версий, файлы-сироты.
+// База не изменяется; сирот старше -grace можно перенести в карантин или удалить
+func main() {
+	verify := flag.Bool("verify", false, "recompute sha256 of every referenced file")
+	grace := flag.Duration("grace", 24*time.Hour, "only files older than this are treated as orphans")
+	orphans := flag.String("orphans", string(service.OrphanReport), "what to do with orphans: report, quarantine or delete")
+	flag.Parse()
+
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+	if err := initConfig(); err != nil {
+		logrus.Fatalf("error initalization config %s", err.Error())
+	}
+	app.CheckIntegrity(service.IntegrityOptions{
+		VerifyHashes: *verify,
+		Grace:        *grace,
+		Orphans:      service.OrphanAction(*orphans),
+	})
+}
+
+func initConfig() error {
+	viper.AddConfigPath("configs")
+	viper.SetConfigName("config")
+	return viper.ReadInConfig()
+}
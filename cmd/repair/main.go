@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"github.com/katenester/doc/internal/app"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// Сверка таблицы documents с хранилищем: заполняет storage_key, size_bytes и sha256
+// для старых записей и сообщает о потерянных и лишних файлах
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report, do not update documents")
+	window := flag.Duration("window", time.Minute, "max distance between created_at and file time when matching legacy rows")
+	flag.Parse()
+
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+	if err := initConfig(); err != nil {
+		logrus.Fatalf("error initalization config %s", err.Error())
+	}
+	app.Repair(*window, *dryRun)
+}
+
+func initConfig() error {
+	viper.AddConfigPath("configs")
+	viper.SetConfigName("config")
+	return viper.ReadInConfig()
+}
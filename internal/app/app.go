@@ -2,39 +2,59 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	"github.com/katenester/doc/internal/repository"
 	"github.com/katenester/doc/internal/repository/postgres/config"
+	"github.com/katenester/doc/internal/repository/storage"
+	"github.com/katenester/doc/internal/repository/storage/local"
+	"github.com/katenester/doc/internal/repository/storage/s3"
 	"github.com/katenester/doc/internal/service"
 	"github.com/katenester/doc/internal/transport"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 )
 
 // Run - Building dependencies and logic
 func Run() {
-	// Download variables env
-	if err := godotenv.Load(); err != nil {
-		logrus.Fatalf("error initalization db password(file env) %s", err.Error())
-	}
-	db, err := config.NewPostgresDB(config.Config{
-		Host:     viper.GetString("db.host"),
-		Port:     viper.GetString("db.port"),
-		Username: viper.GetString("db.username"),
-		DBName:   viper.GetString("db.dbname"),
-		SSLMode:  viper.GetString("db.sslmode"),
-		Password: os.Getenv("DB_PASSWORD"),
-	})
+	db, blobs := initStorage()
+	// Dependency injection for architecture application
+	repos := repository.NewRepository(db, blobs)
+	adminKeys, err := loadAdminKeys()
 	if err != nil {
-		logrus.Fatalf("error initalization db %s", err.Error())
+		logrus.Fatalf("error initalization admin keys %s", err.Error())
 	}
-	// Dependency injection for architecture application
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := transport.NewHandler(services)
+	// Sessions with a non-positive TTL expire as soon as they are issued, so every sign-in would fail
+	sessionTTL := viper.GetDuration("auth.session_ttl")
+	if sessionTTL <= 0 {
+		logrus.Fatalf("auth.session_ttl must be positive, got %q", viper.GetString("auth.session_ttl"))
+	}
+	services := service.NewService(repos, service.Config{
+		SessionTTL: sessionTTL,
+		AdminKeys:  adminKeys,
+		Versions:   viper.GetInt("documents.version_retention"),
+	})
+	handlers := transport.NewHandler(services, transport.Config{
+		AdminMaxFailures:   viper.GetInt("admin.max_failures"),
+		AdminFailureWindow: viper.GetDuration("admin.failure_window"),
+		LinkMaxFailures:    viper.GetInt("share_links.max_failures"),
+		LinkFailureWindow:  viper.GetDuration("share_links.failure_window"),
+		TrustedProxies:     viper.GetStringSlice("http.trusted_proxies"),
+	})
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	go runMaintenance(purgeCtx, services.Document, viper.GetDuration("documents.purge_interval"),
+		viper.GetDuration("documents.trash_retention"), viper.GetDuration("documents.pending_grace"))
+	go runIntegrityCheck(purgeCtx, service.NewRepairService(repos.Document, blobs),
+		viper.GetDuration("integrity.interval"), integrityOptions())
+
 	srv := new(transport.Server)
 	go func() {
 		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
@@ -48,6 +68,7 @@ func Run() {
 	<-quit
 
 	logrus.Println("shutting down server...")
+	stopPurge()
 	if err := srv.Shutdown(context.Background()); err != nil {
 		logrus.Fatalf("error occured while shutting down server %s", err.Error())
 	}
@@ -55,3 +76,129 @@ func Run() {
 		logrus.Fatalf("error occured while closing db %s", err.Error())
 	}
 }
+
+// runMaintenance - Periodically purges documents kept in trash longer than retention and finishes
+// file operations interrupted more than grace ago, until ctx is cancelled
+func runMaintenance(ctx context.Context, docs service.Document, interval time.Duration, retention time.Duration, grace time.Duration) {
+	if interval <= 0 {
+		logrus.Print("documents maintenance disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := docs.PurgeTrash(retention)
+		if err != nil {
+			logrus.Errorf("error purging trash: %s", err.Error())
+		}
+		if purged > 0 {
+			logrus.Printf("purged %d documents from trash", purged)
+		}
+		recovered, err := docs.RecoverBlobs(grace)
+		if err != nil {
+			logrus.Errorf("error recovering pending files: %s", err.Error())
+		}
+		if recovered > 0 {
+			logrus.Printf("finished %d interrupted file operations", recovered)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// initStorage - Connecting to db and file storage from config and env
+func initStorage() (*sqlx.DB, storage.BlobStore) {
+	// Download variables env
+	if err := godotenv.Load(); err != nil {
+		logrus.Fatalf("error initalization db password(file env) %s", err.Error())
+	}
+	db, err := config.NewPostgresDB(config.Config{
+		Host:     viper.GetString("db.host"),
+		Port:     viper.GetString("db.port"),
+		Username: viper.GetString("db.username"),
+		DBName:   viper.GetString("db.dbname"),
+		SSLMode:  viper.GetString("db.sslmode"),
+		Password: os.Getenv("DB_PASSWORD"),
+	})
+	if err != nil {
+		logrus.Fatalf("error initalization db %s", err.Error())
+	}
+	blobs, err := newBlobStore()
+	if err != nil {
+		logrus.Fatalf("error initalization storage %s", err.Error())
+	}
+	return db, blobs
+}
+
+// newBlobStore - Creating file storage by driver from config
+func newBlobStore() (storage.BlobStore, error) {
+	switch driver := viper.GetString("storage.driver"); driver {
+	case "local":
+		return local.NewStore(viper.GetString("storage.local.root"))
+	case "s3":
+		return s3.NewStore(context.Background(), s3.Config{
+			Endpoint:  viper.GetString("storage.s3.endpoint"),
+			Region:    viper.GetString("storage.s3.region"),
+			Bucket:    viper.GetString("storage.s3.bucket"),
+			UseSSL:    viper.GetBool("storage.s3.use_ssl"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// adminKeyConfig - Admin key entry in config: only the SHA-256 of the key is stored
+type adminKeyConfig struct {
+	ID       string `mapstructure:"id"`
+	SHA256   string `mapstructure:"sha256"`
+	NotAfter string `mapstructure:"not_after"` // RFC3339, empty - no expiry
+}
+
+// loadAdminKeys - Reading admin keys from config (admin.keys) and env ADMIN_KEYS="id:sha256[:not_after],..."
+func loadAdminKeys() ([]service.AdminKey, error) {
+	var entries []adminKeyConfig
+	if err := viper.UnmarshalKey("admin.keys", &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range strings.Split(os.Getenv("ADMIN_KEYS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		// not_after in RFC3339 contains ":" itself, so split into at most 3 parts
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid ADMIN_KEYS entry %q", entry)
+		}
+		key := adminKeyConfig{ID: parts[0], SHA256: parts[1]}
+		if len(parts) == 3 {
+			key.NotAfter = parts[2]
+		}
+		entries = append(entries, key)
+	}
+
+	keys := make([]service.AdminKey, 0, len(entries))
+	for _, entry := range entries {
+		hash := strings.ToLower(entry.SHA256)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("admin key %q: sha256 must be %d hex characters", entry.ID, sha256.Size*2)
+		}
+		key := service.AdminKey{ID: entry.ID, SHA256: hash}
+		if entry.NotAfter != "" {
+			notAfter, err := time.Parse(time.RFC3339, entry.NotAfter)
+			if err != nil {
+				return nil, fmt.Errorf("admin key %q: invalid not_after: %w", entry.ID, err)
+			}
+			key.NotAfter = notAfter
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		logrus.Warn("no admin keys configured: only admin sessions can register users")
+	}
+	return keys, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"github.com/katenester/doc/internal/repository"
+	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// CheckIntegrity - One-off check of file storage against documents, versions and pending file operations
+func CheckIntegrity(opts service.IntegrityOptions) {
+	db, blobs := initStorage()
+	defer db.Close()
+
+	repos := repository.NewRepository(db, blobs)
+	repair := service.NewRepairService(repos.Document, blobs)
+	report, err := repair.CheckIntegrity(context.Background(), opts)
+	if err != nil {
+		logrus.Fatalf("error occured while checking storage %s", err.Error())
+	}
+	logIntegrityReport(opts, report)
+}
+
+// integrityOptions - Options of the periodic storage check from config
+func integrityOptions() service.IntegrityOptions {
+	return service.IntegrityOptions{
+		VerifyHashes: viper.GetBool("integrity.verify_hashes"),
+		Grace:        viper.GetDuration("integrity.grace"),
+		Orphans:      service.OrphanAction(viper.GetString("integrity.orphans")),
+	}
+}
+
+// runIntegrityCheck - Periodically checks file storage until ctx is cancelled
+func runIntegrityCheck(ctx context.Context, repair *service.RepairService, interval time.Duration, opts service.IntegrityOptions) {
+	if interval <= 0 {
+		logrus.Print("storage integrity check disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report, err := repair.CheckIntegrity(ctx, opts)
+		if err != nil {
+			logrus.Errorf("error checking storage: %s", err.Error())
+			continue
+		}
+		logIntegrityReport(opts, report)
+	}
+}
+
+func logIntegrityReport(opts service.IntegrityOptions, report service.IntegrityReport) {
+	entry := logrus.WithFields(logrus.Fields{
+		"verify_hashes": opts.VerifyHashes,
+		"orphan_action": opts.Orphans,
+		"checked":       report.Checked,
+		"missing":       report.Missing,
+		"corrupted":     report.Corrupted,
+		"orphaned":      report.Orphaned,
+		"quarantined":   report.Quarantined,
+		"deleted":       report.Deleted,
+	})
+	if len(report.Missing) > 0 || len(report.Corrupted) > 0 {
+		entry.Warn("storage check found problems")
+		return
+	}
+	entry.Info("storage check finished")
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"github.com/katenester/doc/internal/repository"
+	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Repair - One-off reconciliation of documents table with file storage
+func Repair(window time.Duration, dryRun bool) {
+	db, blobs := initStorage()
+	defer db.Close()
+
+	repos := repository.NewRepository(db, blobs)
+	repair := service.NewRepairService(repos.Document, blobs)
+	report, err := repair.Reconcile(context.Background(), window, dryRun)
+	if err != nil {
+		logrus.Fatalf("error occured while repairing documents %s", err.Error())
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"dry_run":  dryRun,
+		"checked":  report.Checked,
+		"adopted":  report.Adopted,
+		"updated":  report.Updated,
+		"missing":  report.Missing,
+		"orphaned": report.Orphaned,
+	}).Info("repair finished")
+}
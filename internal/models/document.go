@@ -1,18 +1,65 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
 
 type Document struct {
-	ID        int       `json:"id"`                  // Идентификатор документа
-	OwnerID   int       `json:"owner_id"`            // Идентификатор владельца (ссылка на пользователя)
-	Name      string    `json:"name"`                // Имя документа
-	Mime      string    `json:"mime"`                // MIME-тип документа
-	File      bool      `json:"file"`                // Флаг наличия файла
-	Public    bool      `json:"public"`              // Флаг публичности документа
-	JSONData  *JSONData `json:"json_data,omitempty"` // Метаданные документа в формате JSON (может отсутствовать)
-	CreatedAt time.Time `json:"created_at"`          // Дата создания документа
-	UpdatedAt time.Time `json:"updated_at"`          // Дата обновления документа
+	ID         int        `json:"id" db:"id"`                           // Идентификатор документа
+	OwnerID    int        `json:"owner_id" db:"owner_id"`               // Идентификатор владельца (ссылка на пользователя)
+	Name       string     `json:"name" db:"name"`                       // Имя документа
+	Mime       string     `json:"mime" db:"mime"`                       // MIME-тип документа
+	File       bool       `json:"file" db:"file"`                       // Флаг наличия файла
+	Public     bool       `json:"public" db:"public"`                   // Флаг публичности документа
+	JSONData   *JSONData  `json:"json_data,omitempty" db:"json_data"`   // Метаданные документа в формате JSON (может отсутствовать)
+	StorageKey string     `json:"-" db:"storage_key"`                   // Ключ содержимого в хранилище файлов
+	Size       int64      `json:"size" db:"size_bytes"`                 // Размер содержимого в байтах
+	SHA256     string     `json:"sha256" db:"sha256"`                   // SHA-256 содержимого в hex
+	Grant      []string   `json:"grant,omitempty" db:"-"`               // Логины пользователей, которым выдан доступ
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`           // Дата создания документа
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`           // Дата обновления документа
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Время перемещения в корзину (nil - документ не удален)
+}
+
+// ETag - сильный ETag документа из SHA-256 содержимого и времени последнего изменения.
+// Меняется и при замене содержимого, и при изменении метаданных. Пустой для несохраненного документа
+func (d Document) ETag() string {
+	if d.UpdatedAt.IsZero() {
+		return ""
+	}
+	version := strconv.FormatInt(d.UpdatedAt.UnixMicro(), 36)
+	if d.SHA256 == "" {
+		return `"` + version + `"`
+	}
+	return `"` + d.SHA256 + "-" + version + `"`
 }
 
 // Структура для метаданных документа, если они есть
 type JSONData map[string]interface{}
+
+// Value - сериализация в JSONB при записи в базу
+func (j JSONData) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return json.Marshal(j)
+}
+
+// Scan - чтение JSONB из базы
+func (j *JSONData) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j)
+	case string:
+		return json.Unmarshal([]byte(v), j)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONData", src)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type DocumentFilter struct {
+	UserID int             // Пользователь, который запрашивает список
+	Login  string          // Логин владельца: только его документы, доступные пользователю (необязательно)
+	Key    string          // Ключ в json_data (необязательно)
+	Value  string          // Значение ключа в json_data (только вместе с Key)
+	Limit  int             // Максимальное количество документов
+	After  *DocumentCursor // Позиция, после которой начинается страница (необязательно)
+}
+
+// Позиция в списке документов, отсортированном по имени, дате создания и идентификатору
+type DocumentCursor struct {
+	Name      string    `json:"n"`
+	CreatedAt time.Time `json:"c"`
+	ID        int       `json:"i"`
+}
@@ -1,7 +1,47 @@
 package models
 
+import "time"
+
 type DocumentGrant struct {
-	ID         int `json:"id"`          // Идентификатор доступа
-	DocumentID int `json:"document_id"` // Идентификатор документа (ссылка на документ)
-	GrantedTo  int `json:"granted_to"`  // Идентификатор пользователя, которому предоставлен доступ
+	ID         int        `json:"id" db:"id"`                   // Идентификатор доступа
+	DocumentID int        `json:"document_id" db:"document_id"` // Идентификатор документа (ссылка на документ)
+	GrantedTo  int        `json:"granted_to" db:"granted_to"`   // Идентификатор пользователя, которому предоставлен доступ
+	Login      string     `json:"login" db:"login"`             // Логин пользователя, которому предоставлен доступ
+	Level      GrantLevel `json:"level" db:"level"`             // Уровень доступа
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`   // Время выдачи доступа
+}
+
+// Уровень доступа к документу. Каждый следующий уровень включает предыдущие
+type GrantLevel string
+
+const (
+	GrantRead    GrantLevel = "read"    // Чтение документа
+	GrantEdit    GrantLevel = "edit"    // Изменение метаданных и замена содержимого
+	GrantReshare GrantLevel = "reshare" // Выдача и отзыв доступа другим пользователям
+)
+
+var grantLevelRank = map[GrantLevel]int{
+	GrantRead:    1,
+	GrantEdit:    2,
+	GrantReshare: 3,
+}
+
+// Valid - известный ли уровень доступа
+func (l GrantLevel) Valid() bool {
+	return grantLevelRank[l] > 0
+}
+
+// Includes - дает ли уровень l права уровня other
+func (l GrantLevel) Includes(other GrantLevel) bool {
+	return l.Valid() && grantLevelRank[l] >= grantLevelRank[other]
+}
+
+// Доступ к документу, выданный группе
+type DocumentGroupGrant struct {
+	ID         int        `json:"id" db:"id"`                   // Идентификатор доступа
+	DocumentID int        `json:"document_id" db:"document_id"` // Идентификатор документа
+	GroupID    int        `json:"group_id" db:"group_id"`       // Идентификатор группы
+	Name       string     `json:"name" db:"name"`               // Название группы
+	Level      GrantLevel `json:"level" db:"level"`             // Уровень доступа
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`   // Время выдачи доступа
 }
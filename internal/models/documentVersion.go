@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Версия содержимого документа. Версии не изменяются: восстановление создает новую версию
+type DocumentVersion struct {
+	ID         int       `json:"id" db:"id"`                   // Идентификатор версии
+	DocumentID int       `json:"document_id" db:"document_id"` // Идентификатор документа
+	Version    int       `json:"version" db:"version"`         // Номер версии (с 1)
+	StorageKey string    `json:"-" db:"storage_key"`           // Ключ содержимого в хранилище файлов
+	Size       int64     `json:"size" db:"size_bytes"`         // Размер содержимого в байтах
+	SHA256     string    `json:"sha256" db:"sha256"`           // SHA-256 содержимого в hex
+	Mime       string    `json:"mime" db:"mime"`               // MIME-тип содержимого
+	Author     string    `json:"author" db:"author"`           // Логин автора версии (пустой, если пользователь удален)
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`   // Время создания версии
+	Current    bool      `json:"current" db:"-"`               // Версия - текущее содержимое документа (последняя)
+}
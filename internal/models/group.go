@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+type Group struct {
+	ID        int       `json:"id" db:"id"`                 // Идентификатор группы
+	Name      string    `json:"name" db:"name"`             // Название группы
+	Role      GroupRole `json:"role,omitempty" db:"role"`   // Роль текущего пользователя в группе (в списке групп)
+	CreatedAt time.Time `json:"created_at" db:"created_at"` // Дата создания группы
+}
+
+type GroupMember struct {
+	GroupID   int       `json:"group_id" db:"group_id"`     // Идентификатор группы
+	UserID    int       `json:"user_id" db:"user_id"`       // Идентификатор участника
+	Login     string    `json:"login" db:"login"`           // Логин участника
+	Role      GroupRole `json:"role" db:"role"`             // Роль участника
+	CreatedAt time.Time `json:"created_at" db:"created_at"` // Дата добавления в группу
+}
+
+// Роль участника группы. Каждая следующая роль включает предыдущие
+type GroupRole string
+
+const (
+	GroupMemberRole GroupRole = "member"  // Участник: получает доступ к документам группы
+	GroupManager    GroupRole = "manager" // Добавляет и удаляет участников
+	GroupOwner      GroupRole = "owner"   // Назначает роли и удаляет группу
+)
+
+var groupRoleRank = map[GroupRole]int{
+	GroupMemberRole: 1,
+	GroupManager:    2,
+	GroupOwner:      3,
+}
+
+// Valid - известная ли роль
+func (r GroupRole) Valid() bool {
+	return groupRoleRank[r] > 0
+}
+
+// Includes - дает ли роль r права роли other
+func (r GroupRole) Includes(other GroupRole) bool {
+	return r.Valid() && groupRoleRank[r] >= groupRoleRank[other]
+}
@@ -2,10 +2,18 @@ package models
 
 import "time"
 
+// Сессия пользователя (без токена: в базе хранится только его хеш)
 type Session struct {
-	ID        int       `json:"id"`         // Идентификатор сессии
-	UserID    int       `json:"user_id"`    // Идентификатор пользователя (ссылка на пользователя)
-	Token     string    `json:"token"`      // Токен сессии
-	CreatedAt time.Time `json:"created_at"` // Дата создания сессии
-	ExpiredAt time.Time `json:"expired_at"` // Дата истечения срока действия сессии
+	ID        int        `json:"id" db:"id"`                 // Идентификатор сессии
+	CreatedAt time.Time  `json:"created_at" db:"created_at"` // Время входа
+	ExpiredAt *time.Time `json:"expired_at" db:"expired_at"` // Время истечения (nil - бессрочная)
+	UserAgent string     `json:"user_agent" db:"user_agent"` // User-Agent клиента
+	IP        string     `json:"ip" db:"ip"`                 // Адрес клиента
+	Current   bool       `json:"current" db:"-"`             // Сессия, с которой сделан запрос
+}
+
+// Сведения о клиенте, открывающем сессию
+type SessionClient struct {
+	UserAgent string
+	IP        string
 }
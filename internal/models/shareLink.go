@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type ShareLink struct {
+	ID           int        `json:"id" db:"id"`                       // Идентификатор ссылки
+	DocumentID   int        `json:"document_id" db:"document_id"`     // Документ, к которому дает доступ ссылка
+	Slug         string     `json:"slug" db:"slug"`                   // Случайная часть адреса /s/<slug>
+	Password     string     `json:"-" db:"password_hash"`             // Хеш пароля (пустой - без пароля)
+	ExpiresAt    *time.Time `json:"expires_at" db:"expires_at"`       // Срок действия (nil - бессрочно)
+	MaxDownloads *int       `json:"max_downloads" db:"max_downloads"` // Максимум обращений (nil - без ограничения)
+	Downloads    int        `json:"downloads" db:"downloads"`         // Число обращений по ссылке
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`       // Дата создания
+	RevokedAt    *time.Time `json:"revoked_at" db:"revoked_at"`       // Время отзыва (nil - действует)
+}
+
+// HasPassword - защищена ли ссылка паролем
+func (l ShareLink) HasPassword() bool {
+	return l.Password != ""
+}
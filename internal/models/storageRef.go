@@ -0,0 +1,11 @@
+package models
+
+// Ссылка на файл хранилища: текущее содержимое документа, одна из его версий
+// или незавершенная операция из журнала pending_blobs
+type StorageRef struct {
+	DocumentID int    `json:"document_id" db:"document_id"` // Идентификатор документа (0 - запись журнала)
+	Version    int    `json:"version" db:"version"`         // Номер версии (0 - текущее содержимое документа)
+	StorageKey string `json:"storage_key" db:"storage_key"` // Ключ файла в хранилище
+	Size       int64  `json:"size" db:"size_bytes"`         // Ожидаемый размер в байтах
+	SHA256     string `json:"sha256" db:"sha256"`           // Ожидаемый SHA-256 в hex (пустой - не проверяется)
+}
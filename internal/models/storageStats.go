@@ -0,0 +1,11 @@
+package models
+
+// Статистика хранилища: сколько занимало бы содержимое всех версий без дедупликации и сколько занимает
+type StorageStats struct {
+	Documents    int   `json:"documents" db:"documents"`         // Документов с файлом (включая корзину)
+	Versions     int   `json:"versions" db:"versions"`           // Версий содержимого
+	Blobs        int   `json:"blobs" db:"blobs"`                 // Уникальных файлов в хранилище
+	LogicalBytes int64 `json:"logical_bytes" db:"logical_bytes"` // Суммарный размер всех версий
+	StoredBytes  int64 `json:"stored_bytes" db:"stored_bytes"`   // Суммарный размер уникальных файлов
+	SavedBytes   int64 `json:"saved_bytes" db:"-"`               // Экономия за счет дедупликации
+}
@@ -3,8 +3,10 @@ package models
 import "time"
 
 type User struct {
-	ID        int       `json:"id"`         // Идентификатор пользователя
-	Login     string    `json:"login"`      // Логин пользователя
-	Password  string    `json:"-"`          // Пароль (хранится как хэш, не передается в JSON)
-	CreatedAt time.Time `json:"created_at"` // Дата создания пользователя
+	ID        int       `json:"id" db:"id"`                 // Идентификатор пользователя
+	Login     string    `json:"login" db:"login"`           // Логин пользователя
+	Password  string    `json:"-" db:"password_hash"`       // Пароль (хранится как хэш, не передается в JSON)
+	IsAdmin   bool      `json:"is_admin" db:"is_admin"`     // Администратор (может регистрировать пользователей)
+	Disabled  bool      `json:"disabled" db:"disabled"`     // Учетная запись отключена администратором
+	CreatedAt time.Time `json:"created_at" db:"created_at"` // Дата создания пользователя
 }
@@ -0,0 +1,6 @@
+package models
+
+type UserFilter struct {
+	Limit int    // Максимальное количество пользователей
+	After string // Логин, после которого начинается страница (необязательно)
+}
@@ -0,0 +1,977 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/katenester/doc/internal/models"
+)
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// Within mocks base method.
+func (m *MockTransactor) Within(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Within", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Within indicates an expected call of Within.
+func (mr *MockTransactorMockRecorder) Within(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Within", reflect.TypeOf((*MockTransactor)(nil).Within), ctx, fn)
+}
+
+// MockAuthorization is a mock of Authorization interface.
+type MockAuthorization struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizationMockRecorder
+}
+
+// MockAuthorizationMockRecorder is the mock recorder for MockAuthorization.
+type MockAuthorizationMockRecorder struct {
+	mock *MockAuthorization
+}
+
+// NewMockAuthorization creates a new mock instance.
+func NewMockAuthorization(ctrl *gomock.Controller) *MockAuthorization {
+	mock := &MockAuthorization{ctrl: ctrl}
+	mock.recorder = &MockAuthorizationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
+	return m.recorder
+}
+
+// ChangePasswordHash mocks base method.
+func (m *MockAuthorization) ChangePasswordHash(ctx context.Context, userID int, hash, keep string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePasswordHash", ctx, userID, hash, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePasswordHash indicates an expected call of ChangePasswordHash.
+func (mr *MockAuthorizationMockRecorder) ChangePasswordHash(ctx, userID, hash, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePasswordHash", reflect.TypeOf((*MockAuthorization)(nil).ChangePasswordHash), ctx, userID, hash, keep)
+}
+
+// CreateUser mocks base method.
+func (m *MockAuthorization) CreateUser(user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
+}
+
+// DeleteSession mocks base method.
+func (m *MockAuthorization) DeleteSession(userID, sessionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockAuthorizationMockRecorder) DeleteSession(userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockAuthorization)(nil).DeleteSession), userID, sessionID)
+}
+
+// DeleteSessions mocks base method.
+func (m *MockAuthorization) DeleteSessions(userID int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessions", userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSessions indicates an expected call of DeleteSessions.
+func (mr *MockAuthorizationMockRecorder) DeleteSessions(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessions", reflect.TypeOf((*MockAuthorization)(nil).DeleteSessions), userID)
+}
+
+// DeleteToken mocks base method.
+func (m *MockAuthorization) DeleteToken(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteToken indicates an expected call of DeleteToken.
+func (mr *MockAuthorizationMockRecorder) DeleteToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteToken", reflect.TypeOf((*MockAuthorization)(nil).DeleteToken), token)
+}
+
+// DeleteUser mocks base method.
+func (m *MockAuthorization) DeleteUser(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockAuthorizationMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockAuthorization)(nil).DeleteUser), ctx, userID)
+}
+
+// GetUserById mocks base method.
+func (m *MockAuthorization) GetUserById(id int) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", id)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockAuthorizationMockRecorder) GetUserById(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockAuthorization)(nil).GetUserById), id)
+}
+
+// GetUserByLogin mocks base method.
+func (m *MockAuthorization) GetUserByLogin(login string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByLogin", login)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByLogin indicates an expected call of GetUserByLogin.
+func (mr *MockAuthorizationMockRecorder) GetUserByLogin(login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockAuthorization)(nil).GetUserByLogin), login)
+}
+
+// GetUserId mocks base method.
+func (m *MockAuthorization) GetUserId(token string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserId", token)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserId indicates an expected call of GetUserId.
+func (mr *MockAuthorizationMockRecorder) GetUserId(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserId", reflect.TypeOf((*MockAuthorization)(nil).GetUserId), token)
+}
+
+// ListSessions mocks base method.
+func (m *MockAuthorization) ListSessions(userID int, current string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", userID, current)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockAuthorizationMockRecorder) ListSessions(userID, current interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockAuthorization)(nil).ListSessions), userID, current)
+}
+
+// ListUsers mocks base method.
+func (m *MockAuthorization) ListUsers(filter models.UserFilter) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", filter)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAuthorizationMockRecorder) ListUsers(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAuthorization)(nil).ListUsers), filter)
+}
+
+// SaveToken mocks base method.
+func (m *MockAuthorization) SaveToken(userID int, token string, ttl time.Duration, client models.SessionClient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveToken", userID, token, ttl, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveToken indicates an expected call of SaveToken.
+func (mr *MockAuthorizationMockRecorder) SaveToken(userID, token, ttl, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveToken", reflect.TypeOf((*MockAuthorization)(nil).SaveToken), userID, token, ttl, client)
+}
+
+// SetDisabled mocks base method.
+func (m *MockAuthorization) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockAuthorizationMockRecorder) SetDisabled(ctx, userID, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockAuthorization)(nil).SetDisabled), ctx, userID, disabled)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockAuthorization) UpdatePasswordHash(userID int, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", userID, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockAuthorizationMockRecorder) UpdatePasswordHash(userID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockAuthorization)(nil).UpdatePasswordHash), userID, hash)
+}
+
+// MockDocument is a mock of Document interface.
+type MockDocument struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentMockRecorder
+}
+
+// MockDocumentMockRecorder is the mock recorder for MockDocument.
+type MockDocumentMockRecorder struct {
+	mock *MockDocument
+}
+
+// NewMockDocument creates a new mock instance.
+func NewMockDocument(ctrl *gomock.Controller) *MockDocument {
+	mock := &MockDocument{ctrl: ctrl}
+	mock.recorder = &MockDocumentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDocument) EXPECT() *MockDocumentMockRecorder {
+	return m.recorder
+}
+
+// AddGrant mocks base method.
+func (m *MockDocument) AddGrant(idFile, idUser int, level models.GrantLevel) (models.DocumentGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGrant", idFile, idUser, level)
+	ret0, _ := ret[0].(models.DocumentGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddGrant indicates an expected call of AddGrant.
+func (mr *MockDocumentMockRecorder) AddGrant(idFile, idUser, level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGrant", reflect.TypeOf((*MockDocument)(nil).AddGrant), idFile, idUser, level)
+}
+
+// AddGroupGrant mocks base method.
+func (m *MockDocument) AddGroupGrant(idFile, idGroup, grantorID int, level models.GrantLevel) (models.DocumentGroupGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupGrant", idFile, idGroup, grantorID, level)
+	ret0, _ := ret[0].(models.DocumentGroupGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddGroupGrant indicates an expected call of AddGroupGrant.
+func (mr *MockDocumentMockRecorder) AddGroupGrant(idFile, idGroup, grantorID, level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupGrant", reflect.TypeOf((*MockDocument)(nil).AddGroupGrant), idFile, idGroup, grantorID, level)
+}
+
+// Create mocks base method.
+func (m *MockDocument) Create(ctx context.Context, doc models.Document, content io.Reader, users []models.User) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, doc, content, users)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDocumentMockRecorder) Create(ctx, doc, content, users interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDocument)(nil).Create), ctx, doc, content, users)
+}
+
+// DeleteFile mocks base method.
+func (m *MockDocument) DeleteFile(ctx context.Context, idFile int, retention time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", ctx, idFile, retention)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockDocumentMockRecorder) DeleteFile(ctx, idFile, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockDocument)(nil).DeleteFile), ctx, idFile, retention)
+}
+
+// DeleteOwned mocks base method.
+func (m *MockDocument) DeleteOwned(ctx context.Context, ownerID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOwned", ctx, ownerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOwned indicates an expected call of DeleteOwned.
+func (mr *MockDocumentMockRecorder) DeleteOwned(ctx, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOwned", reflect.TypeOf((*MockDocument)(nil).DeleteOwned), ctx, ownerID)
+}
+
+// GetAllFile mocks base method.
+func (m *MockDocument) GetAllFile(filter models.DocumentFilter) ([]models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllFile", filter)
+	ret0, _ := ret[0].([]models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllFile indicates an expected call of GetAllFile.
+func (mr *MockDocumentMockRecorder) GetAllFile(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFile", reflect.TypeOf((*MockDocument)(nil).GetAllFile), filter)
+}
+
+// GetGrantLevel mocks base method.
+func (m *MockDocument) GetGrantLevel(idFile, idUser int) (models.GrantLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrantLevel", idFile, idUser)
+	ret0, _ := ret[0].(models.GrantLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrantLevel indicates an expected call of GetGrantLevel.
+func (mr *MockDocumentMockRecorder) GetGrantLevel(idFile, idUser interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrantLevel", reflect.TypeOf((*MockDocument)(nil).GetGrantLevel), idFile, idUser)
+}
+
+// GetGrants mocks base method.
+func (m *MockDocument) GetGrants(idFile int) ([]models.DocumentGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrants", idFile)
+	ret0, _ := ret[0].([]models.DocumentGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrants indicates an expected call of GetGrants.
+func (mr *MockDocumentMockRecorder) GetGrants(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrants", reflect.TypeOf((*MockDocument)(nil).GetGrants), idFile)
+}
+
+// GetGroupGrants mocks base method.
+func (m *MockDocument) GetGroupGrants(idFile int) ([]models.DocumentGroupGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupGrants", idFile)
+	ret0, _ := ret[0].([]models.DocumentGroupGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupGrants indicates an expected call of GetGroupGrants.
+func (mr *MockDocumentMockRecorder) GetGroupGrants(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupGrants", reflect.TypeOf((*MockDocument)(nil).GetGroupGrants), idFile)
+}
+
+// GetInfo mocks base method.
+func (m *MockDocument) GetInfo(idFile int) (models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInfo", idFile)
+	ret0, _ := ret[0].(models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInfo indicates an expected call of GetInfo.
+func (mr *MockDocumentMockRecorder) GetInfo(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInfo", reflect.TypeOf((*MockDocument)(nil).GetInfo), idFile)
+}
+
+// GetVersion mocks base method.
+func (m *MockDocument) GetVersion(idFile, version int) (models.DocumentVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", idFile, version)
+	ret0, _ := ret[0].(models.DocumentVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockDocumentMockRecorder) GetVersion(idFile, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockDocument)(nil).GetVersion), idFile, version)
+}
+
+// ListExpiredTrash mocks base method.
+func (m *MockDocument) ListExpiredTrash(retention time.Duration) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredTrash", retention)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredTrash indicates an expected call of ListExpiredTrash.
+func (mr *MockDocumentMockRecorder) ListExpiredTrash(retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredTrash", reflect.TypeOf((*MockDocument)(nil).ListExpiredTrash), retention)
+}
+
+// ListFiles mocks base method.
+func (m *MockDocument) ListFiles() ([]models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles")
+	ret0, _ := ret[0].([]models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockDocumentMockRecorder) ListFiles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockDocument)(nil).ListFiles))
+}
+
+// ListStorageRefs mocks base method.
+func (m *MockDocument) ListStorageRefs() ([]models.StorageRef, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStorageRefs")
+	ret0, _ := ret[0].([]models.StorageRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStorageRefs indicates an expected call of ListStorageRefs.
+func (mr *MockDocumentMockRecorder) ListStorageRefs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStorageRefs", reflect.TypeOf((*MockDocument)(nil).ListStorageRefs))
+}
+
+// ListTrash mocks base method.
+func (m *MockDocument) ListTrash(ownerID int) ([]models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", ownerID)
+	ret0, _ := ret[0].([]models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockDocumentMockRecorder) ListTrash(ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockDocument)(nil).ListTrash), ownerID)
+}
+
+// ListVersions mocks base method.
+func (m *MockDocument) ListVersions(idFile int) ([]models.DocumentVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersions", idFile)
+	ret0, _ := ret[0].([]models.DocumentVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVersions indicates an expected call of ListVersions.
+func (mr *MockDocumentMockRecorder) ListVersions(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersions", reflect.TypeOf((*MockDocument)(nil).ListVersions), idFile)
+}
+
+// OpenFile mocks base method.
+func (m *MockDocument) OpenFile(storageKey string) (io.ReadSeekCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenFile", storageKey)
+	ret0, _ := ret[0].(io.ReadSeekCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenFile indicates an expected call of OpenFile.
+func (mr *MockDocumentMockRecorder) OpenFile(storageKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFile", reflect.TypeOf((*MockDocument)(nil).OpenFile), storageKey)
+}
+
+// PruneVersions mocks base method.
+func (m *MockDocument) PruneVersions(ctx context.Context, idFile, keep int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneVersions", ctx, idFile, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PruneVersions indicates an expected call of PruneVersions.
+func (mr *MockDocumentMockRecorder) PruneVersions(ctx, idFile, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneVersions", reflect.TypeOf((*MockDocument)(nil).PruneVersions), ctx, idFile, keep)
+}
+
+// RecoverBlobs mocks base method.
+func (m *MockDocument) RecoverBlobs(ctx context.Context, grace time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoverBlobs", ctx, grace)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoverBlobs indicates an expected call of RecoverBlobs.
+func (mr *MockDocumentMockRecorder) RecoverBlobs(ctx, grace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverBlobs", reflect.TypeOf((*MockDocument)(nil).RecoverBlobs), ctx, grace)
+}
+
+// RemoveGrant mocks base method.
+func (m *MockDocument) RemoveGrant(idFile, idUser int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGrant", idFile, idUser)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGrant indicates an expected call of RemoveGrant.
+func (mr *MockDocumentMockRecorder) RemoveGrant(idFile, idUser interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGrant", reflect.TypeOf((*MockDocument)(nil).RemoveGrant), idFile, idUser)
+}
+
+// RemoveGroupGrant mocks base method.
+func (m *MockDocument) RemoveGroupGrant(idFile, idGroup int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGroupGrant", idFile, idGroup)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupGrant indicates an expected call of RemoveGroupGrant.
+func (mr *MockDocumentMockRecorder) RemoveGroupGrant(idFile, idGroup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupGrant", reflect.TypeOf((*MockDocument)(nil).RemoveGroupGrant), idFile, idGroup)
+}
+
+// ReplaceContent mocks base method.
+func (m *MockDocument) ReplaceContent(ctx context.Context, doc models.Document, content io.Reader, mime string, authorID int) (models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceContent", ctx, doc, content, mime, authorID)
+	ret0, _ := ret[0].(models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceContent indicates an expected call of ReplaceContent.
+func (mr *MockDocumentMockRecorder) ReplaceContent(ctx, doc, content, mime, authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceContent", reflect.TypeOf((*MockDocument)(nil).ReplaceContent), ctx, doc, content, mime, authorID)
+}
+
+// RestoreFile mocks base method.
+func (m *MockDocument) RestoreFile(idFile int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreFile", idFile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreFile indicates an expected call of RestoreFile.
+func (mr *MockDocumentMockRecorder) RestoreFile(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreFile", reflect.TypeOf((*MockDocument)(nil).RestoreFile), idFile)
+}
+
+// RestoreVersion mocks base method.
+func (m *MockDocument) RestoreVersion(ctx context.Context, doc models.Document, version models.DocumentVersion, authorID int) (models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreVersion", ctx, doc, version, authorID)
+	ret0, _ := ret[0].(models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreVersion indicates an expected call of RestoreVersion.
+func (mr *MockDocumentMockRecorder) RestoreVersion(ctx, doc, version, authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreVersion", reflect.TypeOf((*MockDocument)(nil).RestoreVersion), ctx, doc, version, authorID)
+}
+
+// SetOwner mocks base method.
+func (m *MockDocument) SetOwner(ctx context.Context, idFile, idUser int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOwner", ctx, idFile, idUser)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOwner indicates an expected call of SetOwner.
+func (mr *MockDocumentMockRecorder) SetOwner(ctx, idFile, idUser interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwner", reflect.TypeOf((*MockDocument)(nil).SetOwner), ctx, idFile, idUser)
+}
+
+// SetPublic mocks base method.
+func (m *MockDocument) SetPublic(idFile int, public bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPublic", idFile, public)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPublic indicates an expected call of SetPublic.
+func (mr *MockDocumentMockRecorder) SetPublic(idFile, public interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPublic", reflect.TypeOf((*MockDocument)(nil).SetPublic), idFile, public)
+}
+
+// SetStorage mocks base method.
+func (m *MockDocument) SetStorage(idFile int, storageKey string, size int64, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStorage", idFile, storageKey, size, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStorage indicates an expected call of SetStorage.
+func (mr *MockDocumentMockRecorder) SetStorage(idFile, storageKey, size, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStorage", reflect.TypeOf((*MockDocument)(nil).SetStorage), idFile, storageKey, size, hash)
+}
+
+// StorageStats mocks base method.
+func (m *MockDocument) StorageStats() (models.StorageStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorageStats")
+	ret0, _ := ret[0].(models.StorageStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StorageStats indicates an expected call of StorageStats.
+func (mr *MockDocumentMockRecorder) StorageStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageStats", reflect.TypeOf((*MockDocument)(nil).StorageStats))
+}
+
+// TransferOwner mocks base method.
+func (m *MockDocument) TransferOwner(ctx context.Context, fromUser, toUser int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferOwner", ctx, fromUser, toUser)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferOwner indicates an expected call of TransferOwner.
+func (mr *MockDocumentMockRecorder) TransferOwner(ctx, fromUser, toUser interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferOwner", reflect.TypeOf((*MockDocument)(nil).TransferOwner), ctx, fromUser, toUser)
+}
+
+// TrashFile mocks base method.
+func (m *MockDocument) TrashFile(idFile int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashFile", idFile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrashFile indicates an expected call of TrashFile.
+func (mr *MockDocumentMockRecorder) TrashFile(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashFile", reflect.TypeOf((*MockDocument)(nil).TrashFile), idFile)
+}
+
+// UpdateInfo mocks base method.
+func (m *MockDocument) UpdateInfo(doc models.Document) (models.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInfo", doc)
+	ret0, _ := ret[0].(models.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateInfo indicates an expected call of UpdateInfo.
+func (mr *MockDocumentMockRecorder) UpdateInfo(doc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInfo", reflect.TypeOf((*MockDocument)(nil).UpdateInfo), doc)
+}
+
+// MockShareLinks is a mock of ShareLinks interface.
+type MockShareLinks struct {
+	ctrl     *gomock.Controller
+	recorder *MockShareLinksMockRecorder
+}
+
+// MockShareLinksMockRecorder is the mock recorder for MockShareLinks.
+type MockShareLinksMockRecorder struct {
+	mock *MockShareLinks
+}
+
+// NewMockShareLinks creates a new mock instance.
+func NewMockShareLinks(ctrl *gomock.Controller) *MockShareLinks {
+	mock := &MockShareLinks{ctrl: ctrl}
+	mock.recorder = &MockShareLinksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShareLinks) EXPECT() *MockShareLinksMockRecorder {
+	return m.recorder
+}
+
+// CountDownload mocks base method.
+func (m *MockShareLinks) CountDownload(linkID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDownload", linkID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDownload indicates an expected call of CountDownload.
+func (mr *MockShareLinksMockRecorder) CountDownload(linkID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDownload", reflect.TypeOf((*MockShareLinks)(nil).CountDownload), linkID)
+}
+
+// CreateLink mocks base method.
+func (m *MockShareLinks) CreateLink(link models.ShareLink) (models.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLink", link)
+	ret0, _ := ret[0].(models.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLink indicates an expected call of CreateLink.
+func (mr *MockShareLinksMockRecorder) CreateLink(link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLink", reflect.TypeOf((*MockShareLinks)(nil).CreateLink), link)
+}
+
+// GetLinkBySlug mocks base method.
+func (m *MockShareLinks) GetLinkBySlug(slug string) (models.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLinkBySlug", slug)
+	ret0, _ := ret[0].(models.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLinkBySlug indicates an expected call of GetLinkBySlug.
+func (mr *MockShareLinksMockRecorder) GetLinkBySlug(slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinkBySlug", reflect.TypeOf((*MockShareLinks)(nil).GetLinkBySlug), slug)
+}
+
+// ListLinks mocks base method.
+func (m *MockShareLinks) ListLinks(idFile int) ([]models.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLinks", idFile)
+	ret0, _ := ret[0].([]models.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLinks indicates an expected call of ListLinks.
+func (mr *MockShareLinksMockRecorder) ListLinks(idFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLinks", reflect.TypeOf((*MockShareLinks)(nil).ListLinks), idFile)
+}
+
+// RevokeLink mocks base method.
+func (m *MockShareLinks) RevokeLink(idFile, linkID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeLink", idFile, linkID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeLink indicates an expected call of RevokeLink.
+func (mr *MockShareLinksMockRecorder) RevokeLink(idFile, linkID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeLink", reflect.TypeOf((*MockShareLinks)(nil).RevokeLink), idFile, linkID)
+}
+
+// MockGroups is a mock of Groups interface.
+type MockGroups struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupsMockRecorder
+}
+
+// MockGroupsMockRecorder is the mock recorder for MockGroups.
+type MockGroupsMockRecorder struct {
+	mock *MockGroups
+}
+
+// NewMockGroups creates a new mock instance.
+func NewMockGroups(ctrl *gomock.Controller) *MockGroups {
+	mock := &MockGroups{ctrl: ctrl}
+	mock.recorder = &MockGroupsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroups) EXPECT() *MockGroupsMockRecorder {
+	return m.recorder
+}
+
+// CreateGroup mocks base method.
+func (m *MockGroups) CreateGroup(ctx context.Context, name string, ownerID int) (models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, name, ownerID)
+	ret0, _ := ret[0].(models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockGroupsMockRecorder) CreateGroup(ctx, name, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockGroups)(nil).CreateGroup), ctx, name, ownerID)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockGroups) DeleteGroup(groupID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", groupID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockGroupsMockRecorder) DeleteGroup(groupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroups)(nil).DeleteGroup), groupID)
+}
+
+// GetMemberRole mocks base method.
+func (m *MockGroups) GetMemberRole(groupID, userID int) (models.GroupRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberRole", groupID, userID)
+	ret0, _ := ret[0].(models.GroupRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberRole indicates an expected call of GetMemberRole.
+func (mr *MockGroupsMockRecorder) GetMemberRole(groupID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberRole", reflect.TypeOf((*MockGroups)(nil).GetMemberRole), groupID, userID)
+}
+
+// ListGroups mocks base method.
+func (m *MockGroups) ListGroups(userID int) ([]models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", userID)
+	ret0, _ := ret[0].([]models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockGroupsMockRecorder) ListGroups(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockGroups)(nil).ListGroups), userID)
+}
+
+// ListMembers mocks base method.
+func (m *MockGroups) ListMembers(groupID int) ([]models.GroupMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", groupID)
+	ret0, _ := ret[0].([]models.GroupMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockGroupsMockRecorder) ListMembers(groupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockGroups)(nil).ListMembers), groupID)
+}
+
+// RemoveMember mocks base method.
+func (m *MockGroups) RemoveMember(groupID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", groupID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockGroupsMockRecorder) RemoveMember(groupID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockGroups)(nil).RemoveMember), groupID, userID)
+}
+
+// SetMember mocks base method.
+func (m *MockGroups) SetMember(groupID, userID int, role models.GroupRole) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMember", groupID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMember indicates an expected call of SetMember.
+func (mr *MockGroupsMockRecorder) SetMember(groupID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMember", reflect.TypeOf((*MockGroups)(nil).SetMember), groupID, userID, role)
+}
@@ -1,62 +1,88 @@
 package auth
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/katenester/doc/internal/models"
 	"github.com/katenester/doc/internal/repository/postgres/config"
+	"github.com/katenester/doc/internal/repository/postgres/transaction"
+	"time"
 )
 
 type AuthPostgres struct {
 	db *sqlx.DB
+	tx *transaction.Manager
 }
 
-func NewAuthPostgres(db *sqlx.DB) *AuthPostgres {
-	return &AuthPostgres{db}
+func NewAuthPostgres(db *sqlx.DB, tx *transaction.Manager) *AuthPostgres {
+	return &AuthPostgres{db: db, tx: tx}
 }
 
 // Создание нового пользователя (регистрация)
 func (a *AuthPostgres) CreateUser(user models.User) error {
-	query := fmt.Sprintf("INSERT INTO %s (login, password_hash) VALUES($1,$2)", config.UsersTable)
-	_, err := a.db.Exec(query, user.Login, user.Password)
-	return err
+	query := fmt.Sprintf("INSERT INTO %s (login, password_hash, is_admin) VALUES($1,$2,$3)", config.UsersTable)
+	_, err := a.db.Exec(query, user.Login, user.Password, user.IsAdmin)
+	return config.WrapError(err)
+}
+
+// Поиск пользователя по логину
+func (a *AuthPostgres) GetUserByLogin(login string) (models.User, error) {
+	var user models.User
+	query := fmt.Sprintf("SELECT id, login, password_hash, is_admin, disabled, created_at FROM %s WHERE login=$1", config.UsersTable)
+	if err := a.db.Get(&user, query, login); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
 }
 
-// Аутентификация
-func (a *AuthPostgres) GetUser(user models.User) (int, error) {
-	var id int
-	query := fmt.Sprintf("SELECT id FROM %s WHERE login=$1 AND password_hash=$2 RETURNING id", config.UsersTable)
-	row := a.db.QueryRow(query, user.Login, user.Password)
-	if err := row.Scan(&id); err != nil {
-		return 0, err
+// Поиск пользователя по ID
+func (a *AuthPostgres) GetUserById(id int) (models.User, error) {
+	var user models.User
+	query := fmt.Sprintf("SELECT id, login, password_hash, is_admin, disabled, created_at FROM %s WHERE id=$1", config.UsersTable)
+	if err := a.db.Get(&user, query, id); err != nil {
+		return models.User{}, err
 	}
-	return id, nil
+	return user, nil
+}
+
+// Замена хеша пароля (перехеширование при входе)
+func (a *AuthPostgres) UpdatePasswordHash(userID int, hash string) error {
+	query := fmt.Sprintf("UPDATE %s SET password_hash=$2 WHERE id=$1", config.UsersTable)
+	_, err := a.db.Exec(query, userID, hash)
+	return err
 }
 
 // Функция для получения ID пользователя по токену
 func (a *AuthPostgres) GetUserId(token string) (int, error) {
 	// Запрос для получения user_id по токену из таблицы sessions
 	var userId int
+	// Сессии отключенных пользователей не принимаются
 	query := `
-		SELECT user_id 
-		FROM sessions 
-		WHERE token = $1 AND expired_at IS NULL OR expired_at > NOW()`
+		SELECT s.user_id 
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token = $1 AND NOT u.disabled AND (s.expired_at IS NULL OR s.expired_at > NOW())`
 
 	// Выполняем запрос и получаем user_id
 	err := a.db.Get(&userId, query, token)
 	if err != nil {
 		// В случае ошибки возвращаем ошибку с пояснением
-		return 0, fmt.Errorf("session not found or expired: %v", err)
+		return 0, fmt.Errorf("session not found or expired: %w", err)
 	}
 
 	// Возвращаем найденный user_id
 	return userId, nil
 }
 
-// Сохранение токена для пользователя
-func (a *AuthPostgres) SaveToken(userID int, token string) error {
-	query := fmt.Sprintf("INSERT INTO %s (user_id, token) VALUES ($1, $2)", config.SessionsTable)
-	_, err := a.db.Exec(query, userID, token)
+// Сохранение токена для пользователя. Срок действия считается на стороне базы,
+// чтобы created_at, expired_at и NOW() в проверке были в одной временной зоне
+func (a *AuthPostgres) SaveToken(userID int, token string, ttl time.Duration, client models.SessionClient) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (user_id, token, created_at, expired_at, user_agent, ip) 
+		VALUES ($1, $2, NOW(), NOW() + make_interval(secs => $3), $4, $5)`, config.SessionsTable)
+	_, err := a.db.Exec(query, userID, token, ttl.Seconds(), client.UserAgent, client.IP)
 	return err
 }
 
@@ -66,3 +92,136 @@ func (a *AuthPostgres) DeleteToken(token string) error {
 	_, err := a.db.Exec(query, token)
 	return err
 }
+
+// Список пользователей по логину с постраничным выводом
+func (a *AuthPostgres) ListUsers(filter models.UserFilter) ([]models.User, error) {
+	var users []models.User
+	query := fmt.Sprintf(`
+		SELECT id, login, password_hash, is_admin, disabled, created_at 
+		FROM %s 
+		WHERE login > $1 
+		ORDER BY login 
+		LIMIT $2`, config.UsersTable)
+	if err := a.db.Select(&users, query, filter.After, filter.Limit); err != nil {
+		return nil, fmt.Errorf("error retrieving users: %w", err)
+	}
+	return users, nil
+}
+
+// Отключение или включение пользователя. При отключении завершаются все его сессии
+func (a *AuthPostgres) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	return a.tx.Within(ctx, func(ctx context.Context) error {
+		q := a.tx.Executor(ctx)
+		query := fmt.Sprintf("UPDATE %s SET disabled = $2 WHERE id = $1", config.UsersTable)
+		if _, err := q.ExecContext(ctx, query, userID, disabled); err != nil {
+			return fmt.Errorf("error updating user: %w", err)
+		}
+		if disabled {
+			query = fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", config.SessionsTable)
+			if _, err := q.ExecContext(ctx, query, userID); err != nil {
+				return fmt.Errorf("error deleting sessions: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Завершение всех сессий пользователя. Возвращает количество завершенных сессий
+func (a *AuthPostgres) DeleteSessions(userID int) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", config.SessionsTable)
+	res, err := a.db.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Удаление пользователя вместе с сессиями, выданными ему доступами и членством в группах.
+// Документы пользователя должны быть переданы или удалены заранее.
+// Группы, где он единственный владелец, получают нового владельца или удаляются, если в них больше никого нет
+func (a *AuthPostgres) DeleteUser(ctx context.Context, userID int) error {
+	return a.tx.Within(ctx, func(ctx context.Context) error {
+		queries := []string{
+			fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", config.SessionsTable),
+			fmt.Sprintf("DELETE FROM %s WHERE granted_to = $1", config.DocumentGrantsTable),
+			// В группах, где пользователь - единственный владелец, владельцем становится
+			// старейший менеджер, а если менеджеров нет - старейший участник
+			fmt.Sprintf(`
+				UPDATE %[1]s m SET role = 'owner' 
+				FROM (
+					SELECT DISTINCT ON (c.group_id) c.group_id, c.user_id 
+					FROM %[1]s c 
+					WHERE c.user_id <> $1 
+					  AND c.group_id IN (SELECT group_id FROM %[1]s WHERE user_id = $1 AND role = 'owner')
+					  AND NOT EXISTS (SELECT 1 FROM %[1]s o WHERE o.group_id = c.group_id AND o.role = 'owner' AND o.user_id <> $1)
+					ORDER BY c.group_id, CASE c.role WHEN 'manager' THEN 0 ELSE 1 END, c.created_at, c.user_id
+				) heir 
+				WHERE m.group_id = heir.group_id AND m.user_id = heir.user_id`, config.GroupMembersTable),
+			// Группы, в которых кроме пользователя никого нет, удаляются
+			fmt.Sprintf(`
+				DELETE FROM %s g 
+				WHERE EXISTS (SELECT 1 FROM %[2]s WHERE group_id = g.id AND user_id = $1) 
+				  AND NOT EXISTS (SELECT 1 FROM %[2]s WHERE group_id = g.id AND user_id <> $1)`,
+				config.GroupsTable, config.GroupMembersTable),
+			fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", config.GroupMembersTable),
+			fmt.Sprintf("DELETE FROM %s WHERE id = $1", config.UsersTable),
+		}
+		for _, query := range queries {
+			if _, err := a.tx.Executor(ctx).ExecContext(ctx, query, userID); err != nil {
+				return fmt.Errorf("error deleting user: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Активные сессии пользователя. current - хеш токена текущей сессии, она помечается в списке
+func (a *AuthPostgres) ListSessions(userID int, current string) ([]models.Session, error) {
+	var sessions []struct {
+		models.Session
+		Token string `db:"token"`
+	}
+	query := fmt.Sprintf(`
+		SELECT id, token, created_at, expired_at, user_agent, ip 
+		FROM %s 
+		WHERE user_id = $1 AND (expired_at IS NULL OR expired_at > NOW()) 
+		ORDER BY created_at DESC`, config.SessionsTable)
+	if err := a.db.Select(&sessions, query, userID); err != nil {
+		return nil, fmt.Errorf("error retrieving sessions: %w", err)
+	}
+	result := make([]models.Session, 0, len(sessions))
+	for _, session := range sessions {
+		session.Session.Current = session.Token == current
+		result = append(result, session.Session)
+	}
+	return result, nil
+}
+
+// Завершение одной сессии пользователя. Чужая или несуществующая сессия - sql.ErrNoRows
+func (a *AuthPostgres) DeleteSession(userID int, sessionID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2", config.SessionsTable)
+	res, err := a.db.Exec(query, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Смена хеша пароля с завершением всех сессий пользователя, кроме текущей (keep - хеш ее токена)
+func (a *AuthPostgres) ChangePasswordHash(ctx context.Context, userID int, hash string, keep string) error {
+	return a.tx.Within(ctx, func(ctx context.Context) error {
+		q := a.tx.Executor(ctx)
+		query := fmt.Sprintf("UPDATE %s SET password_hash = $2 WHERE id = $1", config.UsersTable)
+		if _, err := q.ExecContext(ctx, query, userID, hash); err != nil {
+			return fmt.Errorf("error updating password: %w", err)
+		}
+		query = fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND token <> $2", config.SessionsTable)
+		if _, err := q.ExecContext(ctx, query, userID, keep); err != nil {
+			return fmt.Errorf("error deleting sessions: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"errors"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrAccessDenied - у пользователя нет прав на запрошенную запись
+	ErrAccessDenied = errors.New("access denied")
+	// ErrDuplicate - нарушено ограничение уникальности
+	ErrDuplicate = errors.New("duplicate key")
+	// ErrModified - запись изменена другим запросом после того, как была прочитана
+	ErrModified = errors.New("modified concurrently")
+)
+
+// WrapError - переводит ошибки драйвера postgres в ошибки репозитория
+func WrapError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrDuplicate
+	}
+	return err
+}
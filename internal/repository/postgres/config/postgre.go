@@ -10,6 +10,10 @@ const (
 	DocumentsTable      = "documents"
 	DocumentGrantsTable = "document_grants"
 	SessionsTable       = "sessions"
+	GroupsTable         = "groups"
+	GroupMembersTable   = "group_members"
+	GroupGrantsTable    = "document_group_grants"
+	ShareLinksTable     = "share_links"
 )
 
 type Config struct {
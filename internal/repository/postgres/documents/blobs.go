@@ -0,0 +1,199 @@
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository/postgres/transaction"
+	"io"
+	"time"
+)
+
+// Файлы пишутся и удаляются по схеме write-ahead через таблицу pending_blobs:
+//
+//	загрузка: uploadBlob (запись в журнал) → файл → транзакция документа + acquireUpload → фиксация
+//	удаление: транзакция документа + scheduleDelete (запись в журнал) → фиксация → файл → запись из журнала
+//
+// При сбое на любом шаге в журнале остается запись, по которой RecoverBlobs удаляет файл без документа
+// или просто закрывает запись, если документ на файл ссылается.
+//
+// Содержимое адресуется по sha256 через таблицу blobs: одинаковое содержимое хранится одним файлом,
+// а blobs.refcount считает ссылающиеся на него версии документов. Файл удаляется, когда уходит последняя ссылка
+
+// uploadBlob - запись содержимого под ключом storageKey с предварительной записью в журнал.
+// Журнал пишется вне транзакции документа: запись должна пережить ее откат
+func (d *DocumentPostgres) uploadBlob(content io.Reader, storageKey string, mime string) (int64, string, error) {
+	query := `INSERT INTO pending_blobs (storage_key, operation) VALUES ($1, 'upload')`
+	if _, err := d.db.Exec(query, storageKey); err != nil {
+		return 0, "", fmt.Errorf("failed to register pending file: %w", err)
+	}
+	size, hash, err := d.saveFile(content, storageKey, mime)
+	if err != nil {
+		d.removeBlob(storageKey)
+		return 0, "", fmt.Errorf("failed to save file: %w", err)
+	}
+	return size, hash, nil
+}
+
+// acquireUpload - ссылка на загруженное содержимое (в транзакции, которая сохраняет версию документа).
+// Если такое содержимое уже хранится, возвращается ключ существующего файла, а загруженный дубликат
+// удаляется после фиксации. Иначе загруженный файл регистрируется в blobs и закрывается запись журнала
+func (d *DocumentPostgres) acquireUpload(ctx context.Context, storageKey string, size int64, hash string) (string, error) {
+	q := d.tx.Executor(ctx)
+	var key string
+	query := `
+		INSERT INTO blobs (storage_key, sha256, size_bytes, refcount) VALUES ($1, $2, $3, 1)
+		ON CONFLICT (sha256) WHERE sha256 <> '' DO UPDATE SET refcount = blobs.refcount + 1
+		RETURNING storage_key`
+	if err := q.GetContext(ctx, &key, query, storageKey, hash, size); err != nil {
+		return "", fmt.Errorf("failed to register file: %w", err)
+	}
+	if key != storageKey {
+		return key, d.scheduleDelete(ctx, []string{storageKey})
+	}
+	query = `DELETE FROM pending_blobs WHERE storage_key = $1`
+	if _, err := q.ExecContext(ctx, query, storageKey); err != nil {
+		return "", fmt.Errorf("failed to commit pending file: %w", err)
+	}
+	return key, nil
+}
+
+// retainBlob - еще одна ссылка на уже хранимый файл (восстановление версии).
+// Файлы без записи в blobs ссылки не считают (см. releaseBlobs)
+func (d *DocumentPostgres) retainBlob(ctx context.Context, storageKey string) error {
+	query := `UPDATE blobs SET refcount = refcount + 1 WHERE storage_key = $1`
+	if _, err := d.tx.Executor(ctx).ExecContext(ctx, query, storageKey); err != nil {
+		return fmt.Errorf("failed to reference file: %w", err)
+	}
+	return nil
+}
+
+// releaseBlobs - снятие ссылок удаленных версий (ключ повторяется столько раз, сколько версий удалено).
+// Файлы, на которые больше никто не ссылается, удаляются после фиксации транзакции.
+// Файлы без записи в blobs (ссылки из строк, исправленных repair) удаляются, если на них нет других ссылок
+func (d *DocumentPostgres) releaseBlobs(ctx context.Context, storageKeys []string) error {
+	q := d.tx.Executor(ctx)
+	var untracked, unreferenced []string
+	for _, key := range storageKeys {
+		var refcount int
+		query := `UPDATE blobs SET refcount = refcount - 1 WHERE storage_key = $1 RETURNING refcount`
+		err := q.GetContext(ctx, &refcount, query, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			untracked = append(untracked, key)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to release file: %w", err)
+		}
+		if refcount > 0 {
+			continue
+		}
+		if _, err := q.ExecContext(ctx, `DELETE FROM blobs WHERE storage_key = $1`, key); err != nil {
+			return fmt.Errorf("failed to release file: %w", err)
+		}
+		unreferenced = append(unreferenced, key)
+	}
+
+	unused, err := d.unusedKeys(ctx, untracked)
+	if err != nil {
+		return err
+	}
+	return d.scheduleDelete(ctx, append(unreferenced, unused...))
+}
+
+// scheduleDelete - запись файлов в журнал в транзакции, удаляющей ссылки на них.
+// Сами файлы удаляются только после фиксации транзакции
+func (d *DocumentPostgres) scheduleDelete(ctx context.Context, storageKeys []string) error {
+	query := `
+		INSERT INTO pending_blobs (storage_key, operation) VALUES ($1, 'delete')
+		ON CONFLICT (storage_key) DO UPDATE SET operation = 'delete', created_at = NOW()`
+	for _, key := range storageKeys {
+		if _, err := d.tx.Executor(ctx).ExecContext(ctx, query, key); err != nil {
+			return fmt.Errorf("failed to register file removal: %w", err)
+		}
+	}
+	transaction.AfterCommit(ctx, func() {
+		for _, key := range storageKeys {
+			d.removeBlob(key)
+		}
+	})
+	return nil
+}
+
+// removeBlob - удаление файла и, если оно удалось, его записи из журнала.
+// Ошибки не возвращаются: незакрытую запись обработает RecoverBlobs
+func (d *DocumentPostgres) removeBlob(storageKey string) {
+	if err := d.blobs.Delete(context.Background(), storageKey); err != nil {
+		return
+	}
+	d.db.Exec(`DELETE FROM pending_blobs WHERE storage_key = $1`, storageKey)
+}
+
+// Функция для завершения операций журнала старше grace (более новые могут быть еще в процессе).
+// Файл, на который ссылается документ или версия, остается; остальные файлы удаляются.
+// Возвращает число закрытых записей
+func (d *DocumentPostgres) RecoverBlobs(ctx context.Context, grace time.Duration) (int, error) {
+	var storageKeys []string
+	query := `SELECT storage_key FROM pending_blobs WHERE created_at < NOW() - make_interval(secs => $1)`
+	if err := d.db.SelectContext(ctx, &storageKeys, query, grace.Seconds()); err != nil {
+		return 0, fmt.Errorf("error retrieving pending files: %w", err)
+	}
+
+	recovered := 0
+	for _, key := range storageKeys {
+		err := d.tx.Within(ctx, func(ctx context.Context) error {
+			unused, err := d.unusedKeys(ctx, []string{key})
+			if err != nil {
+				return err
+			}
+			if len(unused) > 0 {
+				if err := d.blobs.Delete(ctx, key); err != nil {
+					return fmt.Errorf("error removing file: %w", err)
+				}
+			}
+			_, err = d.tx.Executor(ctx).ExecContext(ctx, `DELETE FROM pending_blobs WHERE storage_key = $1`, key)
+			return err
+		})
+		if err != nil {
+			return recovered, fmt.Errorf("cannot recover file %s: %w", key, err)
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+// Функция для получения всех ссылок на файлы хранилища: документов, версий и записей журнала
+func (d *DocumentPostgres) ListStorageRefs() ([]models.StorageRef, error) {
+	var refs []models.StorageRef
+	query := `
+		SELECT id AS document_id, 0 AS version, storage_key, size_bytes, sha256 
+		FROM documents WHERE file AND storage_key <> ''
+		UNION ALL
+		SELECT document_id, version, storage_key, size_bytes, sha256 FROM document_versions
+		UNION ALL
+		SELECT 0, 0, storage_key, 0, '' FROM pending_blobs
+		ORDER BY document_id, version`
+	if err := d.db.Select(&refs, query); err != nil {
+		return nil, fmt.Errorf("error retrieving storage references: %w", err)
+	}
+	return refs, nil
+}
+
+// Функция для получения статистики хранилища и экономии от дедупликации
+func (d *DocumentPostgres) StorageStats() (models.StorageStats, error) {
+	var stats models.StorageStats
+	query := `
+		SELECT 
+			(SELECT COUNT(*) FROM documents WHERE file) AS documents,
+			(SELECT COUNT(*) FROM document_versions) AS versions,
+			(SELECT COUNT(*) FROM blobs) AS blobs,
+			(SELECT COALESCE(SUM(size_bytes), 0) FROM document_versions) AS logical_bytes,
+			(SELECT COALESCE(SUM(size_bytes), 0) FROM blobs) AS stored_bytes`
+	if err := d.db.Get(&stats, query); err != nil {
+		return models.StorageStats{}, fmt.Errorf("error retrieving storage stats: %w", err)
+	}
+	stats.SavedBytes = stats.LogicalBytes - stats.StoredBytes
+	return stats, nil
+}
@@ -0,0 +1,203 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository/postgres/transaction"
+	"github.com/katenester/doc/internal/repository/storage"
+	"github.com/katenester/doc/internal/repository/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const docID = 10
+
+// newTestRepo - репозиторий поверх sqlmock и локального хранилища во временной директории
+// с уже сохраненными файлами keys
+func newTestRepo(t *testing.T, keys ...string) (*DocumentPostgres, sqlmock.Sqlmock, *local.Store) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	blobs, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	for _, key := range keys {
+		_, err := blobs.Put(context.Background(), key, strings.NewReader(key), -1, "")
+		require.NoError(t, err)
+	}
+
+	sqlxDB := sqlx.NewDb(db, "postgres")
+	return NewDocumentPostgres(sqlxDB, transaction.NewManager(sqlxDB), blobs), mock, blobs
+}
+
+func query(sql string) string {
+	return regexp.QuoteMeta(sql)
+}
+
+// expectRelease - снятие ссылки на key, после которого остается refcount ссылок
+func expectRelease(mock sqlmock.Sqlmock, key string, refcount int) {
+	mock.ExpectQuery(query(`UPDATE blobs SET refcount = refcount - 1 WHERE storage_key = $1 RETURNING refcount`)).
+		WithArgs(key).WillReturnRows(sqlmock.NewRows([]string{"refcount"}).AddRow(refcount))
+	if refcount == 0 {
+		mock.ExpectExec(query(`DELETE FROM blobs WHERE storage_key = $1`)).
+			WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+// expectRemoval - запись key в журнал в транзакции и удаление файла после фиксации
+func expectRemoval(mock sqlmock.Sqlmock, keys ...string) {
+	for _, key := range keys {
+		mock.ExpectExec(query(`INSERT INTO pending_blobs (storage_key, operation) VALUES ($1, 'delete')`)).
+			WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	for _, key := range keys {
+		mock.ExpectExec(query(`DELETE FROM pending_blobs WHERE storage_key = $1`)).
+			WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+// expectSetContent - запись новой версии документа без перезаписи прежнего содержимого
+func expectSetContent(mock sqlmock.Sqlmock, doc models.Document, key string) {
+	mock.ExpectQuery(query(`INSERT INTO document_versions (document_id, version, storage_key, size_bytes, sha256, mime, author_id, created_at)`)).
+		WithArgs(doc.ID).WillReturnRows(sqlmock.NewRows([]string{"storage_key", "size_bytes", "sha256"}))
+	mock.ExpectQuery(query(`UPDATE documents`)).
+		WithArgs(doc.ID, sqlmock.AnyArg(), key, sqlmock.AnyArg(), sqlmock.AnyArg(), doc.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "storage_key", "updated_at"}).AddRow(doc.ID, key, time.Now()))
+	mock.ExpectExec(query(`INSERT INTO document_versions (document_id, version, storage_key, size_bytes, sha256, mime, author_id)`)).
+		WithArgs(doc.ID, key, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func exists(t *testing.T, blobs *local.Store, key string) bool {
+	_, err := blobs.Stat(context.Background(), key)
+	if err == storage.ErrNotExist {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+func TestPruneVersions_SharedBlob(t *testing.T) {
+	repo, mock, blobs := newTestRepo(t, "shared", "own")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(query(`DELETE FROM document_versions`)).WithArgs(docID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("shared").AddRow("own"))
+	// На "shared" ссылается версия другого документа, на "own" - больше никто
+	expectRelease(mock, "shared", 1)
+	expectRelease(mock, "own", 0)
+	expectRemoval(mock, "own")
+
+	require.NoError(t, repo.PruneVersions(context.Background(), docID, 1))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.True(t, exists(t, blobs, "shared"))
+	assert.False(t, exists(t, blobs, "own"))
+}
+
+func TestDeleteFile_LastReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		refcount int
+		removed  bool
+	}{
+		{name: "blob still referenced", refcount: 1},
+		{name: "last reference", refcount: 0, removed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, blobs := newTestRepo(t, "shared")
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(query(`SELECT id FROM documents`)).WithArgs(docID, float64(0)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(docID))
+			mock.ExpectQuery(query(`SELECT storage_key FROM document_versions WHERE document_id = $1`)).WithArgs(docID).
+				WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("shared"))
+			for _, table := range []string{"document_grants", "document_group_grants"} {
+				mock.ExpectExec(query(`DELETE FROM ` + table + ` WHERE document_id = $1`)).WithArgs(docID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectExec(query(`DELETE FROM documents WHERE id = $1`)).WithArgs(docID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectRelease(mock, "shared", tt.refcount)
+			if tt.removed {
+				expectRemoval(mock, "shared")
+			} else {
+				mock.ExpectCommit()
+			}
+
+			deleted, err := repo.DeleteFile(context.Background(), docID, 0)
+			require.NoError(t, err)
+			assert.True(t, deleted)
+			require.NoError(t, mock.ExpectationsWereMet())
+			assert.Equal(t, !tt.removed, exists(t, blobs, "shared"))
+		})
+	}
+}
+
+func TestReplaceContent_Deduplicated(t *testing.T) {
+	repo, mock, blobs := newTestRepo(t, "existing")
+	doc := models.Document{ID: docID, Name: "report.txt", UpdatedAt: time.Now()}
+	hash := sha256.Sum256([]byte("hello"))
+
+	var uploaded string
+	uploadKey := keyCapture{key: &uploaded}
+	mock.ExpectExec(query(`INSERT INTO pending_blobs (storage_key, operation) VALUES ($1, 'upload')`)).
+		WithArgs(uploadKey).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	// Такое содержимое уже хранится под ключом "existing": ON CONFLICT (sha256) возвращает его
+	mock.ExpectQuery(query(`INSERT INTO blobs (storage_key, sha256, size_bytes, refcount) VALUES ($1, $2, $3, 1)`)).
+		WithArgs(sqlmock.AnyArg(), hex.EncodeToString(hash[:]), int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("existing"))
+	mock.ExpectExec(query(`INSERT INTO pending_blobs (storage_key, operation) VALUES ($1, 'delete')`)).
+		WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSetContent(mock, doc, "existing")
+	mock.ExpectCommit()
+	mock.ExpectExec(query(`DELETE FROM pending_blobs WHERE storage_key = $1`)).
+		WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	updated, err := repo.ReplaceContent(context.Background(), doc, strings.NewReader("hello"), "text/plain", 1)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "existing", updated.StorageKey)
+	require.NotEmpty(t, uploaded)
+	assert.False(t, exists(t, blobs, uploaded), "duplicate upload must be removed")
+	assert.True(t, exists(t, blobs, "existing"))
+}
+
+func TestRestoreVersion_RetainsBlob(t *testing.T) {
+	repo, mock, blobs := newTestRepo(t, "shared")
+	doc := models.Document{ID: docID, UpdatedAt: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query(`UPDATE blobs SET refcount = refcount + 1 WHERE storage_key = $1`)).
+		WithArgs("shared").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSetContent(mock, doc, "shared")
+	mock.ExpectCommit()
+
+	version := models.DocumentVersion{DocumentID: docID, Version: 1, StorageKey: "shared"}
+	_, err := repo.RestoreVersion(context.Background(), doc, version, 1)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.True(t, exists(t, blobs, "shared"))
+}
+
+// keyCapture - аргумент sqlmock, запоминающий ключ загруженного файла
+type keyCapture struct {
+	key *string
+}
+
+func (k keyCapture) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	*k.key = s
+	return ok
+}
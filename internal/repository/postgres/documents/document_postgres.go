@@ -1,254 +1,535 @@
 package documents
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/katenester/doc/internal/models"
-	"mime/multipart"
-	"os"
+	"github.com/katenester/doc/internal/repository/postgres/config"
+	"github.com/katenester/doc/internal/repository/postgres/transaction"
+	"github.com/katenester/doc/internal/repository/storage"
+	"github.com/lib/pq"
+	"io"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 type DocumentPostgres struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	tx    *transaction.Manager
+	blobs storage.BlobStore
 }
 
-func NewDocumentPostgres(db *sqlx.DB) *DocumentPostgres {
-	return &DocumentPostgres{db: db}
+func NewDocumentPostgres(db *sqlx.DB, tx *transaction.Manager, blobs storage.BlobStore) *DocumentPostgres {
+	return &DocumentPostgres{db: db, tx: tx, blobs: blobs}
 }
 
-// Функция для создания документа в базе данных с транзакцией
-func (d *DocumentPostgres) Create(fileHeader *multipart.FileHeader, doc models.Document, users []models.User) error {
-	// Генерация уникального имени для файла
-	fileName := uuid.New().String() + filepath.Ext(fileHeader.Filename)
-
-	// Путь для сохранения файла
-	savePath := "./uploads/" + fileName
-
-	// Сохраняем файл на диск
-	if err := saveFile(fileHeader, savePath); err != nil {
-		return fmt.Errorf("failed to save file: %v", err)
+// Функция для создания документа в базе данных с транзакцией.
+// Содержимое content пишется в хранилище потоком. Если content == nil, создается документ без файла:
+// только данные из json_data
+func (d *DocumentPostgres) Create(ctx context.Context, doc models.Document, content io.Reader, users []models.User) (int, error) {
+	var (
+		storageKey string
+		size       int64
+		hash       string
+		docID      int
+		err        error
+	)
+	if content != nil {
+		// Генерация уникального ключа для содержимого файла
+		storageKey = uuid.New().String() + filepath.Ext(doc.Name)
+
+		// Сохраняем файл в хранилище (с записью в журнал до начала записи)
+		size, hash, err = d.uploadBlob(content, storageKey, doc.Mime)
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	// Начинаем транзакцию
-	tx, err := d.db.Beginx()
-	if err != nil {
-		return fmt.Errorf("failed to start transaction: %v", err)
-	}
+	err = d.tx.Within(ctx, func(ctx context.Context) error {
+		q := d.tx.Executor(ctx)
 
-	// Откат транзакции в случае ошибки
-	defer func() {
+		// Если такое содержимое уже хранится, документ ссылается на существующий файл
+		key := storageKey
+		if content != nil {
+			var err error
+			if key, err = d.acquireUpload(ctx, storageKey, size, hash); err != nil {
+				return err
+			}
+		}
+
+		// Запись документа в таблицу `documents`
+		query := `
+			INSERT INTO documents (owner_id, name, mime, file, public, json_data, storage_key, size_bytes, sha256) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+			RETURNING id`
+		err := q.QueryRowxContext(ctx, query, doc.OwnerID, doc.Name, doc.Mime, content != nil, doc.Public, doc.JSONData,
+			key, size, hash).Scan(&docID)
 		if err != nil {
-			// Удаляем файл, если не удалось начать транзакцию
-			deleteFile(savePath)
-			tx.Rollback()
+			return fmt.Errorf("failed to insert document: %w", err)
 		}
-	}()
 
-	// Запись документа в таблицу `documents`
-	query := `
-		INSERT INTO documents (owner_id, name, mime, file, public, json_data) 
-		VALUES ($1, $2, $3, $4, $5, $6) 
-		RETURNING id`
-	var docID int
-	err = tx.QueryRow(query, doc.OwnerID, doc.Name, doc.Mime, true, doc.Public, doc.JSONData).Scan(&docID)
+		// Сохраняем доступ для пользователей в таблице `document_grants`
+		for _, user := range users {
+			grantQuery := `
+				INSERT INTO document_grants (document_id, granted_to) 
+				VALUES ($1, $2)`
+			if _, err := q.ExecContext(ctx, grantQuery, docID, user.ID); err != nil {
+				return fmt.Errorf("failed to insert document grant: %w", err)
+			}
+		}
+
+		if content == nil {
+			return nil
+		}
+		// Содержимое файла - первая версия документа
+		versionQuery := `
+			INSERT INTO document_versions (document_id, version, storage_key, size_bytes, sha256, mime, author_id) 
+			VALUES ($1, 1, $2, $3, $4, $5, $6)`
+		if _, err := q.ExecContext(ctx, versionQuery, docID, key, size, hash, doc.Mime, doc.OwnerID); err != nil {
+			return fmt.Errorf("failed to insert document version: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to insert document: %v", err)
+		if storageKey != "" {
+			// Удаляем файл, если не удалось сохранить документ
+			d.removeBlob(storageKey)
+		}
+		return 0, err
 	}
+	return docID, nil
+}
 
-	// Сохраняем доступ для пользователей в таблице `document_grants`
-	for _, user := range users {
-		grantQuery := `
-			INSERT INTO document_grants (document_id, granted_to) 
-			VALUES ($1, $2)`
-		_, err := tx.Exec(grantQuery, docID, user.ID)
-		if err != nil {
-			return fmt.Errorf("failed to insert document grant: %v", err)
-		}
+// Функция для сохранения файла в хранилище. Возвращает размер и SHA-256 записанного содержимого
+func (d *DocumentPostgres) saveFile(content io.Reader, storageKey string, mime string) (int64, string, error) {
+	// Копируем содержимое потоком, не читая файл целиком в память, и попутно считаем хеш
+	hash := sha256.New()
+	info, err := d.blobs.Put(context.Background(), storageKey, io.TeeReader(content, hash), -1, mime)
+	if err != nil {
+		return 0, "", fmt.Errorf("cannot write file: %w", err)
 	}
 
-	// Подтверждаем транзакцию, если все прошло успешно
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+	return info.Size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Функция для получения метаданных документа (без чтения содержимого).
+// Права доступа проверяет сервис
+func (d *DocumentPostgres) GetInfo(idFile int) (models.Document, error) {
+	var doc models.Document
+	query := `
+		SELECT id, owner_id, name, mime, file, public, json_data, storage_key, size_bytes, sha256, created_at, updated_at, deleted_at 
+		FROM documents 
+		WHERE id = $1`
+	if err := d.db.Get(&doc, query, idFile); err != nil {
+		return models.Document{}, fmt.Errorf("document not found: %w", err)
 	}
+	return doc, nil
+}
 
-	return nil
+// Документы, доступ к которым выдан пользователю $1 лично или через группу
+const grantedDocuments = `
+	SELECT document_id FROM document_grants WHERE granted_to = $1
+	UNION
+	SELECT gg.document_id FROM document_group_grants gg
+	JOIN group_members m ON m.group_id = gg.group_id
+	WHERE m.user_id = $1`
+
+// Функция для получения уровня доступа пользователя к документу ("" - доступ не выдан).
+// Если доступ выдан и лично, и через группы, возвращается наибольший уровень
+func (d *DocumentPostgres) GetGrantLevel(idFile int, idUser int) (models.GrantLevel, error) {
+	var level models.GrantLevel
+	query := `
+		SELECT level FROM (
+			SELECT level FROM document_grants 
+			WHERE document_id = $1 AND granted_to = $2
+			UNION ALL
+			SELECT gg.level FROM document_group_grants gg
+			JOIN group_members m ON m.group_id = gg.group_id
+			WHERE gg.document_id = $1 AND m.user_id = $2
+		) levels
+		ORDER BY CASE level WHEN 'reshare' THEN 3 WHEN 'edit' THEN 2 ELSE 1 END DESC
+		LIMIT 1`
+	err := d.db.Get(&level, query, idFile, idUser)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error retrieving document grant: %w", err)
+	}
+	return level, nil
 }
 
-// Функция для сохранения файла на диск
-func saveFile(fileHeader *multipart.FileHeader, savePath string) error {
-	// Открываем файл
-	file, err := fileHeader.Open()
+// Функция для открытия содержимого документа в хранилище
+func (d *DocumentPostgres) OpenFile(storageKey string) (io.ReadSeekCloser, error) {
+	content, _, err := d.blobs.Get(context.Background(), storageKey)
 	if err != nil {
-		return fmt.Errorf("cannot open file: %v", err)
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
-	defer file.Close()
+	return content, nil
+}
 
-	// Создаем директорию, если ее нет
-	if err := os.MkdirAll(filepath.Dir(savePath), os.ModePerm); err != nil {
-		return fmt.Errorf("cannot create directory: %v", err)
+// Функция для получения списка документов, доступных пользователю, с фильтрацией и постраничным выводом
+func (d *DocumentPostgres) GetAllFile(filter models.DocumentFilter) ([]models.Document, error) {
+	// Шаг 1: Собираем условия выборки
+	args := []interface{}{filter.UserID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
 	}
 
-	// Создаем файл на диске
-	out, err := os.Create(savePath)
-	if err != nil {
-		return fmt.Errorf("cannot create file: %v", err)
+	// Документы из корзины в списки не попадают
+	conditions := []string{`d.deleted_at IS NULL`}
+	if filter.Login == "" {
+		// Свои документы и документы, к которым выдан доступ (лично или группе)
+		conditions = append(conditions,
+			`(d.owner_id = $1 OR d.id IN (`+grantedDocuments+`))`)
+	} else {
+		// Документы другого пользователя, которыми он поделился со мной
+		conditions = append(conditions,
+			fmt.Sprintf(`d.owner_id = (SELECT id FROM users WHERE login = %s)`, arg(filter.Login)),
+			`(d.owner_id = $1 OR d.public OR d.id IN (`+grantedDocuments+`))`)
+	}
+	if filter.Key != "" {
+		if filter.Value != "" {
+			conditions = append(conditions, fmt.Sprintf(`d.json_data ->> %s = %s`, arg(filter.Key), arg(filter.Value)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf(`d.json_data ? %s`, arg(filter.Key)))
+		}
 	}
-	defer out.Close()
+	if filter.After != nil {
+		// Keyset-пагинация: строки строго после последней выданной в порядке сортировки
+		conditions = append(conditions, fmt.Sprintf(`(d.name, d.created_at, d.id) > (%s, %s, %s)`,
+			arg(filter.After.Name), arg(filter.After.CreatedAt), arg(filter.After.ID)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT d.id, d.owner_id, d.name, d.mime, d.file, d.public, d.storage_key, d.size_bytes, d.sha256, d.created_at, d.updated_at 
+		FROM documents d
+		WHERE %s
+		ORDER BY d.name, d.created_at, d.id
+		LIMIT %s`, strings.Join(conditions, " AND "), arg(filter.Limit)) // Сортировка по имени и дате создания
 
-	// Копируем содержимое
-	if _, err = file.Seek(0, 0); err != nil {
-		return fmt.Errorf("cannot seek file: %v", err)
+	var documents []models.Document
+	if err := d.db.Select(&documents, query, args...); err != nil {
+		return nil, fmt.Errorf("error retrieving documents: %w", err)
 	}
-	if _, err = out.ReadFrom(file); err != nil {
-		return fmt.Errorf("cannot write file: %v", err)
+
+	// Шаг 2: Логины пользователей, которым выдан доступ к документам
+	if err := d.loadGrants(documents); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return documents, nil
 }
 
-// Функция для удаления файла с диска
-func deleteFile(savePath string) error {
-	err := os.Remove(savePath)
-	if err != nil {
-		return fmt.Errorf("failed to delete file: %v", err)
+// Функция для заполнения списка логинов с доступом к документам
+func (d *DocumentPostgres) loadGrants(documents []models.Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(documents))
+	index := make(map[int]int, len(documents))
+	for i, doc := range documents {
+		ids = append(ids, int64(doc.ID))
+		index[doc.ID] = i
 	}
-	return nil
-}
 
-// Функция для получения одного файла
-func (d *DocumentPostgres) GetFile(idUser int, idFile int) (*multipart.FileHeader, models.Document, error) {
-	// Шаг 1: Проверка наличия документа в базе данных
-	var doc models.Document
+	var grants []struct {
+		DocumentID int    `db:"document_id"`
+		Login      string `db:"login"`
+	}
 	query := `
-		SELECT id, owner_id, name, mime, file, public 
-		FROM documents 
-		WHERE id = $1`
-	err := d.db.Get(&doc, query, idFile)
-	if err != nil {
-		// Если документ не найден, возвращаем ошибку
-		return nil, models.Document{}, fmt.Errorf("document not found: %v", err)
+		SELECT g.document_id, u.login 
+		FROM document_grants g
+		JOIN users u ON u.id = g.granted_to
+		WHERE g.document_id = ANY($1)
+		ORDER BY u.login`
+	if err := d.db.Select(&grants, query, pq.Array(ids)); err != nil {
+		return fmt.Errorf("error retrieving document grants: %w", err)
 	}
-
-	// Шаг 2: Проверка прав доступа пользователя
-	if doc.Public == false {
-		// Проверка наличия записи в таблице доступа (document_grants)
-		var accessCheck int
-		accessQuery := `
-			SELECT 1 FROM document_grants 
-			WHERE document_id = $1 AND granted_to = $2`
-		err = d.db.Get(&accessCheck, accessQuery, idFile, idUser)
-		if err != nil || accessCheck == 0 {
-			// Если доступ запрещен
-			return nil, models.Document{}, fmt.Errorf("user does not have access to this document")
-		}
+	for _, grant := range grants {
+		doc := &documents[index[grant.DocumentID]]
+		doc.Grant = append(doc.Grant, grant.Login)
 	}
+	return nil
+}
 
-	// Шаг 3: Получение файла с диска
-	filePath := filepath.Join("./uploads", doc.Name) // Предположим, что файлы хранятся в каталоге "uploads"
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, models.Document{}, fmt.Errorf("failed to open file: %v", err)
-	}
-	defer file.Close()
+// Функция для окончательного удаления документа из корзины вместе со ссылками на файлы всех его версий.
+// Документ удаляется, только если под блокировкой он все еще в корзине и пролежал там не меньше retention;
+// иначе (документ восстановлен или уже удален) возвращается false.
+// Файлы, на которые больше никто не ссылается, удаляются после фиксации транзакции (через журнал pending_blobs)
+func (d *DocumentPostgres) DeleteFile(ctx context.Context, idFile int, retention time.Duration) (bool, error) {
+	deleted := false
+	err := d.tx.Within(ctx, func(ctx context.Context) error {
+		q := d.tx.Executor(ctx)
+
+		// Шаг 1: Блокировка документа до конца транзакции. Условие перепроверяется после ожидания блокировки,
+		// поэтому документ, восстановленный параллельным запросом, не удаляется
+		var id int
+		query := `
+			SELECT id FROM documents 
+			WHERE id = $1 AND deleted_at IS NOT NULL AND deleted_at <= NOW() - make_interval(secs => $2) 
+			FOR UPDATE`
+		err := q.GetContext(ctx, &id, query, idFile, retention.Seconds())
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error retrieving document: %w", err)
+		}
 
-	// Шаг 4: Создание multipart.FileHeader для файла
-	fileHeader := &multipart.FileHeader{
-		Filename: doc.Name,
-		Size:     int64(len(doc.Name)),      // Для примера, размер файла, если его нужно вычислить другим способом, используйте len(file)
-		Header:   make(map[string][]string), // Дополнительные заголовки для файла
-	}
+		// Шаг 2: Ссылки на файлы - по одной на каждую версию, и ключ документа, если версий у него нет
+		var storageKeys []string
+		query = `
+			SELECT storage_key FROM document_versions WHERE document_id = $1
+			UNION ALL
+			SELECT storage_key FROM documents 
+			WHERE id = $1 AND storage_key <> '' 
+			  AND NOT EXISTS (SELECT 1 FROM document_versions WHERE document_id = $1)`
+		if err := q.SelectContext(ctx, &storageKeys, query, idFile); err != nil {
+			return fmt.Errorf("error retrieving document versions: %w", err)
+		}
 
-	// Здесь можно вставить код для добавления других данных в header, если нужно, например, mime-тип.
+		// Шаг 3: Удаление доступов, затем самого документа (версии и ссылки удаляются каскадно)
+		for _, query := range []string{
+			`DELETE FROM document_grants WHERE document_id = $1`,
+			`DELETE FROM document_group_grants WHERE document_id = $1`,
+			`DELETE FROM documents WHERE id = $1`,
+		} {
+			if _, err := q.ExecContext(ctx, query, idFile); err != nil {
+				return fmt.Errorf("error deleting document: %w", err)
+			}
+		}
 
-	// Шаг 5: Возвращаем метаданные документа и сам файл
-	return fileHeader, doc, nil
+		// Шаг 4: Снятие ссылок; файлы без ссылок удаляются после фиксации
+		if err := d.releaseBlobs(ctx, storageKeys); err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
 }
 
-// Функция для получения всех файлов
-func (d *DocumentPostgres) GetAllFile(idUser int) ([]*multipart.FileHeader, []models.Document, error) {
-	// Шаг 1: Получение всех документов пользователя и доступных ему файлов
+// Функция для получения всех документов с файлами (для сверки с хранилищем)
+func (d *DocumentPostgres) ListFiles() ([]models.Document, error) {
 	var documents []models.Document
 	query := `
-		SELECT id, owner_id, name, mime, file, public 
+		SELECT id, owner_id, name, mime, file, public, storage_key, size_bytes, sha256, created_at 
 		FROM documents
-		WHERE owner_id = $1 OR id IN (SELECT document_id FROM document_grants WHERE granted_to = $1)
-		ORDER BY name, created_at` // Сортировка по имени и дате создания
+		WHERE file = TRUE
+		ORDER BY id`
+	if err := d.db.Select(&documents, query); err != nil {
+		return nil, fmt.Errorf("error retrieving documents: %w", err)
+	}
+	return documents, nil
+}
 
-	err := d.db.Select(&documents, query, idUser)
-	if err != nil {
-		return nil, nil, fmt.Errorf("error retrieving documents: %v", err)
-	}
-
-	// Шаг 2: Для каждого документа получить файл
-	var fileHeaders []*multipart.FileHeader
-	for _, doc := range documents {
-		// Проверка, есть ли файл (file = true)
-		if doc.File {
-			filePath := filepath.Join("./uploads", doc.Name) // Путь к файлу на диске
-			file, err := os.Open(filePath)
-			if err != nil {
-				// Если файл не найден, продолжим с другим документом
-				continue
-			}
-			defer file.Close()
+// Функция для обновления ключа, размера и хеша содержимого документа
+func (d *DocumentPostgres) SetStorage(idFile int, storageKey string, size int64, hash string) error {
+	query := `
+		UPDATE documents 
+		SET storage_key = $2, size_bytes = $3, sha256 = $4 
+		WHERE id = $1`
+	if _, err := d.db.Exec(query, idFile, storageKey, size, hash); err != nil {
+		return fmt.Errorf("error updating document storage: %w", err)
+	}
+	return nil
+}
 
-			// Создание multipart.FileHeader
-			fileHeader := &multipart.FileHeader{
-				Filename: doc.Name,
-				Size:     int64(len(doc.Name)),      // Размер файла, это можно заменить на реальный размер
-				Header:   make(map[string][]string), // Дополнительные заголовки для файла
-			}
+// Функция для передачи всех документов пользователя другому владельцу.
+// Доступы нового владельца к этим документам становятся лишними и удаляются
+func (d *DocumentPostgres) TransferOwner(ctx context.Context, fromUser int, toUser int) error {
+	return d.tx.Within(ctx, func(ctx context.Context) error {
+		q := d.tx.Executor(ctx)
+		query := `
+			DELETE FROM document_grants 
+			WHERE granted_to = $2 AND document_id IN (SELECT id FROM documents WHERE owner_id = $1)`
+		if _, err := q.ExecContext(ctx, query, fromUser, toUser); err != nil {
+			return fmt.Errorf("error deleting document grants: %w", err)
+		}
+		query = `UPDATE documents SET owner_id = $2, updated_at = NOW() WHERE owner_id = $1`
+		if _, err := q.ExecContext(ctx, query, fromUser, toUser); err != nil {
+			return fmt.Errorf("error transferring documents: %w", err)
+		}
+		return nil
+	})
+}
 
-			// Добавляем в срез заголовков файлов
-			fileHeaders = append(fileHeaders, fileHeader)
+// Функция для удаления всех документов пользователя вместе с файлами
+func (d *DocumentPostgres) DeleteOwned(ctx context.Context, ownerID int) error {
+	return d.tx.Within(ctx, func(ctx context.Context) error {
+		q := d.tx.Executor(ctx)
+
+		var storageKeys []string
+		query := `
+			SELECT v.storage_key FROM document_versions v JOIN documents d ON d.id = v.document_id WHERE d.owner_id = $1
+			UNION ALL
+			SELECT storage_key FROM documents d 
+			WHERE owner_id = $1 AND storage_key <> '' 
+			  AND NOT EXISTS (SELECT 1 FROM document_versions v WHERE v.document_id = d.id)`
+		if err := q.SelectContext(ctx, &storageKeys, query, ownerID); err != nil {
+			return fmt.Errorf("error retrieving documents: %w", err)
+		}
+		for _, query := range []string{
+			`DELETE FROM document_grants WHERE document_id IN (SELECT id FROM documents WHERE owner_id = $1)`,
+			`DELETE FROM document_group_grants WHERE document_id IN (SELECT id FROM documents WHERE owner_id = $1)`,
+			`DELETE FROM documents WHERE owner_id = $1`,
+		} {
+			if _, err := q.ExecContext(ctx, query, ownerID); err != nil {
+				return fmt.Errorf("error deleting documents: %w", err)
+			}
 		}
-	}
 
-	// Шаг 3: Возвращаем список файлов и документов
-	return fileHeaders, documents, nil
+		// Файлы без ссылок удаляются после фиксации: если удаление не удалось, его завершит RecoverBlobs
+		return d.releaseBlobs(ctx, storageKeys)
+	})
 }
 
-// Функция для удаления файла
-func (d *DocumentPostgres) DeleteFile(idUser int, idFile int) error {
-	// Шаг 1: Получение документа из базы данных
-	var doc models.Document
+// Функция для получения списка доступов к документу
+func (d *DocumentPostgres) GetGrants(idFile int) ([]models.DocumentGrant, error) {
+	var grants []models.DocumentGrant
 	query := `
-		SELECT id, owner_id, name 
-		FROM documents
-		WHERE id = $1`
-	err := d.db.Get(&doc, query, idFile)
-	if err != nil {
-		return fmt.Errorf("error retrieving document: %v", err)
+		SELECT g.id, g.document_id, g.granted_to, u.login, g.level, g.created_at 
+		FROM document_grants g
+		JOIN users u ON u.id = g.granted_to
+		WHERE g.document_id = $1
+		ORDER BY u.login`
+	if err := d.db.Select(&grants, query, idFile); err != nil {
+		return nil, fmt.Errorf("error retrieving document grants: %w", err)
 	}
+	return grants, nil
+}
 
-	// Шаг 2: Проверка, является ли пользователь владельцем файла
-	if doc.OwnerID != idUser {
-		return fmt.Errorf("user does not have permission to delete this file")
+// Функция для выдачи доступа к документу. Повторная выдача меняет уровень существующего доступа
+func (d *DocumentPostgres) AddGrant(idFile int, idUser int, level models.GrantLevel) (models.DocumentGrant, error) {
+	var grant models.DocumentGrant
+	query := `
+		WITH g AS (
+			INSERT INTO document_grants (document_id, granted_to, level) 
+			VALUES ($1, $2, $3)
+			ON CONFLICT (document_id, granted_to) DO UPDATE SET level = EXCLUDED.level
+			RETURNING id, document_id, granted_to, level, created_at
+		)
+		SELECT g.id, g.document_id, g.granted_to, u.login, g.level, g.created_at 
+		FROM g
+		JOIN users u ON u.id = g.granted_to`
+	if err := d.db.Get(&grant, query, idFile, idUser, level); err != nil {
+		return models.DocumentGrant{}, fmt.Errorf("failed to insert document grant: %w", err)
 	}
+	return grant, nil
+}
 
-	// Шаг 3: Удаление файла с диска
-	filePath := filepath.Join("./uploads", doc.Name) // Путь к файлу на диске
-	err = os.Remove(filePath)
+// Функция для отзыва доступа к документу. Если доступа не было - sql.ErrNoRows
+func (d *DocumentPostgres) RemoveGrant(idFile int, idUser int) error {
+	query := `DELETE FROM document_grants WHERE document_id = $1 AND granted_to = $2`
+	res, err := d.db.Exec(query, idFile, idUser)
 	if err != nil {
-		return fmt.Errorf("error removing file: %v", err)
+		return fmt.Errorf("error deleting document grant: %w", err)
 	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("document grant not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
 
-	// Шаг 4: Удаление записи о документе из базы данных
-	deleteQuery := `DELETE FROM documents WHERE id = $1`
-	_, err = d.db.Exec(deleteQuery, idFile)
-	if err != nil {
-		return fmt.Errorf("error deleting document record: %v", err)
+// Функция для изменения признака публичности документа
+func (d *DocumentPostgres) SetPublic(idFile int, public bool) error {
+	query := `UPDATE documents SET public = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := d.db.Exec(query, idFile, public); err != nil {
+		return fmt.Errorf("error updating document: %w", err)
 	}
+	return nil
+}
 
-	// Шаг 5: Удаление всех записей о доступах к документу из таблицы document_grants
-	deleteGrantsQuery := `DELETE FROM document_grants WHERE document_id = $1`
-	_, err = d.db.Exec(deleteGrantsQuery, idFile)
-	if err != nil {
-		return fmt.Errorf("error deleting document grants: %v", err)
+// Функция для передачи документа новому владельцу. Доступ нового владельца становится лишним и удаляется
+func (d *DocumentPostgres) SetOwner(ctx context.Context, idFile int, idUser int) error {
+	return d.tx.Within(ctx, func(ctx context.Context) error {
+		q := d.tx.Executor(ctx)
+		query := `DELETE FROM document_grants WHERE document_id = $1 AND granted_to = $2`
+		if _, err := q.ExecContext(ctx, query, idFile, idUser); err != nil {
+			return fmt.Errorf("error deleting document grant: %w", err)
+		}
+		query = `UPDATE documents SET owner_id = $2, updated_at = NOW() WHERE id = $1`
+		if _, err := q.ExecContext(ctx, query, idFile, idUser); err != nil {
+			return fmt.Errorf("error transferring document: %w", err)
+		}
+		return nil
+	})
+}
+
+// Функция для получения списка доступов групп к документу
+func (d *DocumentPostgres) GetGroupGrants(idFile int) ([]models.DocumentGroupGrant, error) {
+	var grants []models.DocumentGroupGrant
+	query := `
+		SELECT gg.id, gg.document_id, gg.group_id, g.name, gg.level, gg.created_at 
+		FROM document_group_grants gg
+		JOIN groups g ON g.id = gg.group_id
+		WHERE gg.document_id = $1
+		ORDER BY g.name`
+	if err := d.db.Select(&grants, query, idFile); err != nil {
+		return nil, fmt.Errorf("error retrieving document group grants: %w", err)
 	}
+	return grants, nil
+}
 
-	// Успешное выполнение
+// Функция для выдачи доступа к документу группе от имени участника группы grantorID.
+// Несуществующая группа и группа, в которой grantorID не состоит, - sql.ErrNoRows:
+// иначе перебором идентификаторов можно узнать названия чужих групп
+func (d *DocumentPostgres) AddGroupGrant(idFile int, idGroup int, grantorID int, level models.GrantLevel) (models.DocumentGroupGrant, error) {
+	var grant models.DocumentGroupGrant
+	query := `
+		WITH gg AS (
+			INSERT INTO document_group_grants (document_id, group_id, level) 
+			SELECT $1, id, $3 FROM groups 
+			WHERE id = $2 AND EXISTS (SELECT 1 FROM group_members WHERE group_id = $2 AND user_id = $4)
+			ON CONFLICT (document_id, group_id) DO UPDATE SET level = EXCLUDED.level
+			RETURNING id, document_id, group_id, level, created_at
+		)
+		SELECT gg.id, gg.document_id, gg.group_id, g.name, gg.level, gg.created_at 
+		FROM gg
+		JOIN groups g ON g.id = gg.group_id`
+	if err := d.db.Get(&grant, query, idFile, idGroup, level, grantorID); err != nil {
+		return models.DocumentGroupGrant{}, fmt.Errorf("failed to insert document group grant: %w", err)
+	}
+	return grant, nil
+}
+
+// Функция для отзыва доступа к документу у группы. Если доступа не было - sql.ErrNoRows
+func (d *DocumentPostgres) RemoveGroupGrant(idFile int, idGroup int) error {
+	query := `DELETE FROM document_group_grants WHERE document_id = $1 AND group_id = $2`
+	res, err := d.db.Exec(query, idFile, idGroup)
+	if err != nil {
+		return fmt.Errorf("error deleting document group grant: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("document group grant not found: %w", sql.ErrNoRows)
+	}
 	return nil
 }
+
+// Функция для изменения метаданных документа (имя, MIME-тип, публичность, json_data).
+// Запись обновляется, только если updated_at не изменился с момента чтения doc, иначе - config.ErrModified
+func (d *DocumentPostgres) UpdateInfo(doc models.Document) (models.Document, error) {
+	var updated models.Document
+	query := `
+		UPDATE documents 
+		SET name = $2, mime = $3, public = $4, json_data = $5, updated_at = NOW() 
+		WHERE id = $1 AND updated_at = $6
+		RETURNING id, owner_id, name, mime, file, public, json_data, storage_key, size_bytes, sha256, created_at, updated_at`
+	err := d.db.Get(&updated, query, doc.ID, doc.Name, doc.Mime, doc.Public, doc.JSONData, doc.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Document{}, fmt.Errorf("document %d: %w", doc.ID, config.ErrModified)
+	}
+	if err != nil {
+		return models.Document{}, fmt.Errorf("error updating document: %w", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,60 @@
+package documents
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"time"
+)
+
+// Функция для перемещения документа в корзину
+func (d *DocumentPostgres) TrashFile(idFile int) error {
+	query := `UPDATE documents SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	return d.execAffected(query, idFile)
+}
+
+// Функция для восстановления документа из корзины
+func (d *DocumentPostgres) RestoreFile(idFile int) error {
+	query := `UPDATE documents SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	return d.execAffected(query, idFile)
+}
+
+// execAffected - выполнение запроса, изменяющего одну строку; sql.ErrNoRows, если строка не найдена
+func (d *DocumentPostgres) execAffected(query string, args ...interface{}) error {
+	result, err := d.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("document not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// Функция для получения документов пользователя в корзине (недавно удаленные первыми)
+func (d *DocumentPostgres) ListTrash(ownerID int) ([]models.Document, error) {
+	var documents []models.Document
+	query := `
+		SELECT id, owner_id, name, mime, file, public, storage_key, size_bytes, sha256, created_at, updated_at, deleted_at 
+		FROM documents
+		WHERE owner_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id`
+	if err := d.db.Select(&documents, query, ownerID); err != nil {
+		return nil, fmt.Errorf("error retrieving trash: %w", err)
+	}
+	return documents, nil
+}
+
+// Функция для получения документов, пролежавших в корзине дольше retention
+func (d *DocumentPostgres) ListExpiredTrash(retention time.Duration) ([]int, error) {
+	var ids []int
+	query := `SELECT id FROM documents WHERE deleted_at < NOW() - make_interval(secs => $1) ORDER BY deleted_at`
+	if err := d.db.Select(&ids, query, retention.Seconds()); err != nil {
+		return nil, fmt.Errorf("error retrieving trash: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,192 @@
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository/postgres/config"
+	"io"
+	"path/filepath"
+)
+
+const versionColumns = `v.id, v.document_id, v.version, v.storage_key, v.size_bytes, v.sha256, COALESCE(v.mime, '') AS mime, 
+		COALESCE(u.login, '') AS author, v.created_at`
+
+// Функция для замены содержимого документа с сохранением идентификатора и доступов.
+// Новое содержимое становится новой версией (если оно уже хранится - ссылкой на существующий файл);
+// прежние версии сохраняются.
+// Запись обновляется, только если updated_at не изменился с момента чтения doc, иначе - config.ErrModified
+func (d *DocumentPostgres) ReplaceContent(ctx context.Context, doc models.Document, content io.Reader, mime string, authorID int) (models.Document, error) {
+	storageKey := uuid.New().String() + filepath.Ext(doc.Name)
+	size, hash, err := d.uploadBlob(content, storageKey, mime)
+	if err != nil {
+		return models.Document{}, err
+	}
+
+	var updated models.Document
+	err = d.tx.Within(ctx, func(ctx context.Context) error {
+		key, err := d.acquireUpload(ctx, storageKey, size, hash)
+		if err != nil {
+			return err
+		}
+		version := models.DocumentVersion{StorageKey: key, Size: size, SHA256: hash, Mime: mime}
+		updated, err = d.setContent(ctx, doc, version, authorID)
+		return err
+	})
+	if err != nil {
+		d.removeBlob(storageKey)
+		return models.Document{}, err
+	}
+	return updated, nil
+}
+
+// Функция для восстановления версии: ее содержимое становится текущим в виде новой версии
+func (d *DocumentPostgres) RestoreVersion(ctx context.Context, doc models.Document, version models.DocumentVersion, authorID int) (models.Document, error) {
+	var updated models.Document
+	err := d.tx.Within(ctx, func(ctx context.Context) error {
+		if err := d.retainBlob(ctx, version.StorageKey); err != nil {
+			return err
+		}
+		var err error
+		updated, err = d.setContent(ctx, doc, version, authorID)
+		return err
+	})
+	return updated, err
+}
+
+// setContent - замена текущего содержимого документа и запись новой версии (в транзакции из ctx)
+func (d *DocumentPostgres) setContent(ctx context.Context, doc models.Document, version models.DocumentVersion, authorID int) (models.Document, error) {
+	q := d.tx.Executor(ctx)
+	if err := d.adoptContent(ctx, doc.ID); err != nil {
+		return models.Document{}, err
+	}
+
+	var updated models.Document
+	query := `
+		UPDATE documents 
+		SET file = TRUE, mime = $2, storage_key = $3, size_bytes = $4, sha256 = $5, updated_at = NOW() 
+		WHERE id = $1 AND updated_at = $6
+		RETURNING id, owner_id, name, mime, file, public, json_data, storage_key, size_bytes, sha256, created_at, updated_at`
+	err := q.GetContext(ctx, &updated, query, doc.ID, version.Mime, version.StorageKey, version.Size, version.SHA256, doc.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Document{}, fmt.Errorf("document %d: %w", doc.ID, config.ErrModified)
+	}
+	if err != nil {
+		return models.Document{}, fmt.Errorf("error updating document: %w", err)
+	}
+
+	query = `
+		INSERT INTO document_versions (document_id, version, storage_key, size_bytes, sha256, mime, author_id) 
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6 
+		FROM document_versions WHERE document_id = $1`
+	_, err = q.ExecContext(ctx, query, doc.ID, version.StorageKey, version.Size, version.SHA256, version.Mime, authorID)
+	if err != nil {
+		return models.Document{}, fmt.Errorf("failed to insert document version: %w", err)
+	}
+	return updated, nil
+}
+
+// adoptContent - запись текущего содержимого документа первой версией, если версий у него нет
+// (файлы, загруженные до появления версий или подобранные сверкой хранилища).
+// Без этого прежний файл не попал бы в историю и не был бы учтен в blobs
+func (d *DocumentPostgres) adoptContent(ctx context.Context, idFile int) error {
+	q := d.tx.Executor(ctx)
+
+	var current models.DocumentVersion
+	query := `
+		INSERT INTO document_versions (document_id, version, storage_key, size_bytes, sha256, mime, author_id, created_at) 
+		SELECT id, 1, storage_key, size_bytes, sha256, mime, owner_id, updated_at 
+		FROM documents 
+		WHERE id = $1 AND file AND storage_key <> '' 
+		  AND NOT EXISTS (SELECT 1 FROM document_versions WHERE document_id = $1)
+		RETURNING storage_key, size_bytes, sha256`
+	err := q.GetContext(ctx, &current, query, idFile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert document version: %w", err)
+	}
+
+	// Если такое же содержимое уже хранится под другим ключом, файл учитывается без хеша,
+	// как дубликаты при миграции blobs
+	query = `
+		INSERT INTO blobs (storage_key, sha256, size_bytes, refcount) 
+		SELECT $1, CASE WHEN EXISTS (SELECT 1 FROM blobs WHERE sha256 = $2) THEN '' ELSE $2 END, $3, 1
+		ON CONFLICT (storage_key) DO UPDATE SET refcount = blobs.refcount + 1`
+	if _, err := q.ExecContext(ctx, query, current.StorageKey, current.SHA256, current.Size); err != nil {
+		return fmt.Errorf("error registering blob: %w", err)
+	}
+	return nil
+}
+
+// Функция для получения списка версий документа (новые первыми)
+func (d *DocumentPostgres) ListVersions(idFile int) ([]models.DocumentVersion, error) {
+	var versions []models.DocumentVersion
+	query := `
+		SELECT ` + versionColumns + ` 
+		FROM document_versions v
+		LEFT JOIN users u ON u.id = v.author_id
+		WHERE v.document_id = $1
+		ORDER BY v.version DESC`
+	if err := d.db.Select(&versions, query, idFile); err != nil {
+		return nil, fmt.Errorf("error retrieving document versions: %w", err)
+	}
+	return versions, nil
+}
+
+// Функция для получения одной версии документа
+func (d *DocumentPostgres) GetVersion(idFile int, version int) (models.DocumentVersion, error) {
+	var v models.DocumentVersion
+	query := `
+		SELECT ` + versionColumns + ` 
+		FROM document_versions v
+		LEFT JOIN users u ON u.id = v.author_id
+		WHERE v.document_id = $1 AND v.version = $2`
+	if err := d.db.Get(&v, query, idFile, version); err != nil {
+		return models.DocumentVersion{}, fmt.Errorf("document version not found: %w", err)
+	}
+	return v, nil
+}
+
+// Функция для удаления старых версий документа: остаются keep последних версий и текущая.
+// Файл удаляется, только если на него больше не ссылается ни одна версия
+func (d *DocumentPostgres) PruneVersions(ctx context.Context, idFile int, keep int) error {
+	return d.tx.Within(ctx, func(ctx context.Context) error {
+		var storageKeys []string
+		query := `
+			DELETE FROM document_versions 
+			WHERE document_id = $1 
+			  AND storage_key <> (SELECT storage_key FROM documents WHERE id = $1)
+			  AND version NOT IN (
+				SELECT version FROM document_versions WHERE document_id = $1 ORDER BY version DESC LIMIT $2
+			  )
+			RETURNING storage_key`
+		if err := d.tx.Executor(ctx).SelectContext(ctx, &storageKeys, query, idFile, keep); err != nil {
+			return fmt.Errorf("error deleting document versions: %w", err)
+		}
+		return d.releaseBlobs(ctx, storageKeys)
+	})
+}
+
+// unusedKeys - ключи из storageKeys, на которые больше не ссылаются ни документы, ни версии, ни blobs
+func (d *DocumentPostgres) unusedKeys(ctx context.Context, storageKeys []string) ([]string, error) {
+	var unused []string
+	for _, key := range storageKeys {
+		var used bool
+		query := `
+			SELECT EXISTS (SELECT 1 FROM documents WHERE storage_key = $1) 
+			    OR EXISTS (SELECT 1 FROM document_versions WHERE storage_key = $1)
+			    OR EXISTS (SELECT 1 FROM blobs WHERE storage_key = $1)`
+		if err := d.tx.Executor(ctx).GetContext(ctx, &used, query, key); err != nil {
+			return nil, fmt.Errorf("error checking file references: %w", err)
+		}
+		if !used {
+			unused = append(unused, key)
+		}
+	}
+	return unused, nil
+}
@@ -0,0 +1,123 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository/postgres/config"
+	"github.com/katenester/doc/internal/repository/postgres/transaction"
+)
+
+type GroupsPostgres struct {
+	db *sqlx.DB
+	tx *transaction.Manager
+}
+
+func NewGroupsPostgres(db *sqlx.DB, tx *transaction.Manager) *GroupsPostgres {
+	return &GroupsPostgres{db: db, tx: tx}
+}
+
+// Создание группы. Создатель становится ее владельцем
+func (g *GroupsPostgres) CreateGroup(ctx context.Context, name string, ownerID int) (models.Group, error) {
+	group := models.Group{Name: name, Role: models.GroupOwner}
+	err := g.tx.Within(ctx, func(ctx context.Context) error {
+		q := g.tx.Executor(ctx)
+		query := fmt.Sprintf("INSERT INTO %s (name) VALUES ($1) RETURNING id, created_at", config.GroupsTable)
+		if err := q.QueryRowxContext(ctx, query, name).Scan(&group.ID, &group.CreatedAt); err != nil {
+			return fmt.Errorf("error creating group: %w", err)
+		}
+		query = fmt.Sprintf("INSERT INTO %s (group_id, user_id, role) VALUES ($1, $2, $3)", config.GroupMembersTable)
+		if _, err := q.ExecContext(ctx, query, group.ID, ownerID, models.GroupOwner); err != nil {
+			return fmt.Errorf("error adding group owner: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Group{}, err
+	}
+	return group, nil
+}
+
+// Группы, в которых состоит пользователь, с его ролью
+func (g *GroupsPostgres) ListGroups(userID int) ([]models.Group, error) {
+	var groups []models.Group
+	query := fmt.Sprintf(`
+		SELECT g.id, g.name, m.role, g.created_at 
+		FROM %s g
+		JOIN %s m ON m.group_id = g.id
+		WHERE m.user_id = $1
+		ORDER BY g.name`, config.GroupsTable, config.GroupMembersTable)
+	if err := g.db.Select(&groups, query, userID); err != nil {
+		return nil, fmt.Errorf("error retrieving groups: %w", err)
+	}
+	return groups, nil
+}
+
+// Удаление группы вместе с участниками и выданными ей доступами
+func (g *GroupsPostgres) DeleteGroup(groupID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", config.GroupsTable)
+	res, err := g.db.Exec(query, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting group: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("group not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// Роль пользователя в группе ("" - не состоит в группе). Несуществующая группа - sql.ErrNoRows
+func (g *GroupsPostgres) GetMemberRole(groupID int, userID int) (models.GroupRole, error) {
+	var role sql.NullString
+	query := fmt.Sprintf(`
+		SELECT m.role 
+		FROM %s g
+		LEFT JOIN %s m ON m.group_id = g.id AND m.user_id = $2
+		WHERE g.id = $1`, config.GroupsTable, config.GroupMembersTable)
+	if err := g.db.Get(&role, query, groupID, userID); err != nil {
+		return "", fmt.Errorf("group not found: %w", err)
+	}
+	return models.GroupRole(role.String), nil
+}
+
+// Участники группы
+func (g *GroupsPostgres) ListMembers(groupID int) ([]models.GroupMember, error) {
+	var members []models.GroupMember
+	query := fmt.Sprintf(`
+		SELECT m.group_id, m.user_id, u.login, m.role, m.created_at 
+		FROM %s m
+		JOIN %s u ON u.id = m.user_id
+		WHERE m.group_id = $1
+		ORDER BY u.login`, config.GroupMembersTable, config.UsersTable)
+	if err := g.db.Select(&members, query, groupID); err != nil {
+		return nil, fmt.Errorf("error retrieving group members: %w", err)
+	}
+	return members, nil
+}
+
+// Добавление участника в группу или изменение его роли
+func (g *GroupsPostgres) SetMember(groupID int, userID int, role models.GroupRole) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (group_id, user_id, role) 
+		VALUES ($1, $2, $3)
+		ON CONFLICT (group_id, user_id) DO UPDATE SET role = EXCLUDED.role`, config.GroupMembersTable)
+	if _, err := g.db.Exec(query, groupID, userID, role); err != nil {
+		return fmt.Errorf("error adding group member: %w", err)
+	}
+	return nil
+}
+
+// Удаление участника из группы. Если пользователь не состоит в группе - sql.ErrNoRows
+func (g *GroupsPostgres) RemoveMember(groupID int, userID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE group_id = $1 AND user_id = $2", config.GroupMembersTable)
+	res, err := g.db.Exec(query, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting group member: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("group member not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package links
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository/postgres/config"
+)
+
+const linkColumns = "id, document_id, slug, password_hash, expires_at, max_downloads, downloads, created_at, revoked_at"
+
+type LinksPostgres struct {
+	db *sqlx.DB
+}
+
+func NewLinksPostgres(db *sqlx.DB) *LinksPostgres {
+	return &LinksPostgres{db: db}
+}
+
+// Создание ссылки на документ
+func (l *LinksPostgres) CreateLink(link models.ShareLink) (models.ShareLink, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (document_id, slug, password_hash, expires_at, max_downloads) 
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING %s`, config.ShareLinksTable, linkColumns)
+	var created models.ShareLink
+	err := l.db.Get(&created, query, link.DocumentID, link.Slug, link.Password, link.ExpiresAt, link.MaxDownloads)
+	if err != nil {
+		return models.ShareLink{}, config.WrapError(err)
+	}
+	return created, nil
+}
+
+// Все ссылки документа, включая отозванные и истекшие
+func (l *LinksPostgres) ListLinks(idFile int) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	query := fmt.Sprintf(`
+		SELECT %s 
+		FROM %s 
+		WHERE document_id = $1 
+		ORDER BY created_at DESC`, linkColumns, config.ShareLinksTable)
+	if err := l.db.Select(&links, query, idFile); err != nil {
+		return nil, fmt.Errorf("error retrieving share links: %w", err)
+	}
+	return links, nil
+}
+
+// Ссылка по slug
+func (l *LinksPostgres) GetLinkBySlug(slug string) (models.ShareLink, error) {
+	var link models.ShareLink
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE slug = $1", linkColumns, config.ShareLinksTable)
+	if err := l.db.Get(&link, query, slug); err != nil {
+		return models.ShareLink{}, fmt.Errorf("share link not found: %w", err)
+	}
+	return link, nil
+}
+
+// Отзыв ссылки. Если у документа нет такой действующей ссылки - sql.ErrNoRows
+func (l *LinksPostgres) RevokeLink(idFile int, linkID int) error {
+	query := fmt.Sprintf(`
+		UPDATE %s SET revoked_at = NOW() 
+		WHERE id = $1 AND document_id = $2 AND revoked_at IS NULL`, config.ShareLinksTable)
+	res, err := l.db.Exec(query, linkID, idFile)
+	if err != nil {
+		return fmt.Errorf("error revoking share link: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("share link not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// Учет обращения по ссылке. Проверка срока и лимита и увеличение счетчика выполняются одним запросом,
+// чтобы параллельные обращения не превысили max_downloads. false - ссылка больше не действует
+func (l *LinksPostgres) CountDownload(linkID int) (bool, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s SET downloads = downloads + 1 
+		WHERE id = $1 AND revoked_at IS NULL 
+		  AND (expires_at IS NULL OR expires_at > NOW()) 
+		  AND (max_downloads IS NULL OR downloads < max_downloads)`, config.ShareLinksTable)
+	res, err := l.db.Exec(query, linkID)
+	if err != nil {
+		return false, fmt.Errorf("error counting share link access: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
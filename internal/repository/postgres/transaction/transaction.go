@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// Executor - то, через что репозитории выполняют запросы: *sqlx.DB или *sqlx.Tx
+type Executor interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
+}
+
+// Manager - единица работы (unit of work) поверх базы. Транзакция передается через context:
+// репозиторий, получивший ctx внутри Within, выполняет запросы в той же транзакции
+type Manager struct {
+	db *sqlx.DB
+}
+
+func NewManager(db *sqlx.DB) *Manager {
+	return &Manager{db: db}
+}
+
+type unitKey struct{}
+
+// unit - открытая транзакция и действия, которые выполняются только после ее фиксации
+type unit struct {
+	tx          *sqlx.Tx
+	afterCommit []func()
+}
+
+// Within выполняет fn в транзакции. Вложенный вызов присоединяется к внешней транзакции,
+// фиксация и действия AfterCommit выполняются один раз - при выходе из внешнего Within.
+// Если fn вернула ошибку, транзакция откатывается
+func (m *Manager) Within(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(unitKey{}).(*unit); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	// После Commit откат ничего не делает
+	defer tx.Rollback()
+
+	u := &unit{tx: tx}
+	if err := fn(context.WithValue(ctx, unitKey{}, u)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, action := range u.afterCommit {
+		action()
+	}
+	return nil
+}
+
+// Executor возвращает транзакцию из ctx, а вне Within - саму базу
+func (m *Manager) Executor(ctx context.Context) Executor {
+	if u, ok := ctx.Value(unitKey{}).(*unit); ok {
+		return u.tx
+	}
+	return m.db
+}
+
+// AfterCommit откладывает action до фиксации транзакции из ctx (при откате action не выполняется).
+// Вне транзакции action выполняется сразу
+func AfterCommit(ctx context.Context, action func()) {
+	if u, ok := ctx.Value(unitKey{}).(*unit); ok {
+		u.afterCommit = append(u.afterCommit, action)
+		return
+	}
+	action()
+}
@@ -1,36 +1,118 @@
 package repository
 
 import (
+	"context"
 	"github.com/jmoiron/sqlx"
 	"github.com/katenester/doc/internal/models"
 	"github.com/katenester/doc/internal/repository/postgres/auth"
+	"github.com/katenester/doc/internal/repository/postgres/config"
 	"github.com/katenester/doc/internal/repository/postgres/documents"
-	"mime/multipart"
+	"github.com/katenester/doc/internal/repository/postgres/groups"
+	"github.com/katenester/doc/internal/repository/postgres/links"
+	"github.com/katenester/doc/internal/repository/postgres/transaction"
+	"github.com/katenester/doc/internal/repository/storage"
+	"io"
+	"time"
 )
 
+//go:generate mockgen -source=repository.go -destination=mocks/mock.go
+
+// Ошибки репозиториев, которые сервисы переводят в доменные (кроме них - sql.ErrNoRows)
+var (
+	ErrAccessDenied = config.ErrAccessDenied
+	ErrDuplicate    = config.ErrDuplicate
+	ErrModified     = config.ErrModified
+)
+
+// Transactor - единица работы: вызовы репозиториев с ctx из fn выполняются в одной транзакции
+type Transactor interface {
+	Within(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type Authorization interface {
 	CreateUser(user models.User) error
-	GetUser(user models.User) (int, error)
+	GetUserByLogin(login string) (models.User, error)
+	GetUserById(id int) (models.User, error)
+	UpdatePasswordHash(userID int, hash string) error
 	GetUserId(token string) (int, error)
-	SaveToken(userID int, token string) error
+	SaveToken(userID int, token string, ttl time.Duration, client models.SessionClient) error
 	DeleteToken(token string) error
+	ListUsers(filter models.UserFilter) ([]models.User, error)
+	SetDisabled(ctx context.Context, userID int, disabled bool) error
+	DeleteSessions(userID int) (int64, error)
+	DeleteUser(ctx context.Context, userID int) error
+	ListSessions(userID int, current string) ([]models.Session, error)
+	DeleteSession(userID int, sessionID int) error
+	ChangePasswordHash(ctx context.Context, userID int, hash string, keep string) error
 }
 
 type Document interface {
-	Create(fileHeader *multipart.FileHeader, doc models.Document, users []models.User) error
-	GetFile(idUser int, idFile int) (*multipart.FileHeader, models.Document, error)
-	GetAllFile(idUser int) ([]*multipart.FileHeader, []models.Document, error)
-	DeleteFile(idUser int, idFile int) error
+	Create(ctx context.Context, doc models.Document, content io.Reader, users []models.User) (int, error)
+	GetInfo(idFile int) (models.Document, error)
+	GetGrantLevel(idFile int, idUser int) (models.GrantLevel, error)
+	OpenFile(storageKey string) (io.ReadSeekCloser, error)
+	GetAllFile(filter models.DocumentFilter) ([]models.Document, error)
+	DeleteFile(ctx context.Context, idFile int, retention time.Duration) (bool, error)
+	ListFiles() ([]models.Document, error)
+	SetStorage(idFile int, storageKey string, size int64, hash string) error
+	TransferOwner(ctx context.Context, fromUser int, toUser int) error
+	DeleteOwned(ctx context.Context, ownerID int) error
+	GetGrants(idFile int) ([]models.DocumentGrant, error)
+	AddGrant(idFile int, idUser int, level models.GrantLevel) (models.DocumentGrant, error)
+	RemoveGrant(idFile int, idUser int) error
+	SetPublic(idFile int, public bool) error
+	SetOwner(ctx context.Context, idFile int, idUser int) error
+	UpdateInfo(doc models.Document) (models.Document, error)
+	ReplaceContent(ctx context.Context, doc models.Document, content io.Reader, mime string, authorID int) (models.Document, error)
+	ListVersions(idFile int) ([]models.DocumentVersion, error)
+	GetVersion(idFile int, version int) (models.DocumentVersion, error)
+	RestoreVersion(ctx context.Context, doc models.Document, version models.DocumentVersion, authorID int) (models.Document, error)
+	PruneVersions(ctx context.Context, idFile int, keep int) error
+	TrashFile(idFile int) error
+	RestoreFile(idFile int) error
+	ListTrash(ownerID int) ([]models.Document, error)
+	ListExpiredTrash(retention time.Duration) ([]int, error)
+	RecoverBlobs(ctx context.Context, grace time.Duration) (int, error)
+	ListStorageRefs() ([]models.StorageRef, error)
+	StorageStats() (models.StorageStats, error)
+	GetGroupGrants(idFile int) ([]models.DocumentGroupGrant, error)
+	AddGroupGrant(idFile int, idGroup int, grantorID int, level models.GrantLevel) (models.DocumentGroupGrant, error)
+	RemoveGroupGrant(idFile int, idGroup int) error
+}
+
+type ShareLinks interface {
+	CreateLink(link models.ShareLink) (models.ShareLink, error)
+	ListLinks(idFile int) ([]models.ShareLink, error)
+	GetLinkBySlug(slug string) (models.ShareLink, error)
+	RevokeLink(idFile int, linkID int) error
+	CountDownload(linkID int) (bool, error)
+}
+
+type Groups interface {
+	CreateGroup(ctx context.Context, name string, ownerID int) (models.Group, error)
+	ListGroups(userID int) ([]models.Group, error)
+	DeleteGroup(groupID int) error
+	GetMemberRole(groupID int, userID int) (models.GroupRole, error)
+	ListMembers(groupID int) ([]models.GroupMember, error)
+	SetMember(groupID int, userID int, role models.GroupRole) error
+	RemoveMember(groupID int, userID int) error
 }
 
 type Repository struct {
+	Transactor
 	Authorization
 	Document
+	Groups
+	ShareLinks
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
+func NewRepository(db *sqlx.DB, blobs storage.BlobStore) *Repository {
+	tx := transaction.NewManager(db)
 	return &Repository{
-		Authorization: auth.NewAuthPostgres(db),
-		Document:      documents.NewDocumentPostgres(db),
+		Transactor:    tx,
+		Authorization: auth.NewAuthPostgres(db, tx),
+		Document:      documents.NewDocumentPostgres(db, tx, blobs),
+		Groups:        groups.NewGroupsPostgres(db, tx),
+		ShareLinks:    links.NewLinksPostgres(db),
 	}
 }
@@ -0,0 +1,155 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/repository/storage"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store - хранилище файлов в локальной директории
+type Store struct {
+	root string
+}
+
+func NewStore(root string) (*Store, error) {
+	if root == "" {
+		return nil, errors.New("local storage root is empty")
+	}
+	// Создаем директорию, если ее нет
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("cannot create storage directory: %w", err)
+	}
+	return &Store{root: root}, nil
+}
+
+// path - преобразует ключ в путь на диске, не позволяя выйти за пределы root
+func (s *Store) path(key string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(key))
+	if clean == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (storage.ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы читатели не увидели недописанный файл
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("cannot create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return storage.ObjectInfo{}, fmt.Errorf("cannot write file: %w", err)
+	}
+	if size >= 0 && written != size {
+		tmp.Close()
+		return storage.ObjectInfo{}, fmt.Errorf("short write: expected %d bytes, got %d", size, written)
+	}
+	if err := tmp.Close(); err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("cannot write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("cannot save file: %w", err)
+	}
+	return s.Stat(ctx, key)
+}
+
+func (s *Store) Get(ctx context.Context, key string) (io.ReadSeekCloser, storage.ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, storage.ObjectInfo{}, wrapErr(err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, storage.ObjectInfo{}, wrapErr(err)
+	}
+	return file, objectInfo(key, stat), nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return storage.ObjectInfo{}, wrapErr(err)
+	}
+	return objectInfo(key, stat), nil
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) List(ctx context.Context, prefix string, fn func(storage.ObjectInfo) error) error {
+	return filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Пропускаем директории и недописанные временные файлы
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(objectInfo(key, stat))
+	})
+}
+
+func objectInfo(key string, stat fs.FileInfo) storage.ObjectInfo {
+	return storage.ObjectInfo{
+		Key:     key,
+		Size:    stat.Size(),
+		ModTime: stat.ModTime(),
+	}
+}
+
+func wrapErr(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return storage.ErrNotExist
+	}
+	return err
+}
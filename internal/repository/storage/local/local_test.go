@@ -0,0 +1,131 @@
+package local
+
+import (
+	"context"
+	"github.com/katenester/doc/internal/repository/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestStore(t *testing.T) (*Store, string) {
+	root := filepath.Join(t.TempDir(), "blobs")
+	store, err := NewStore(root)
+	require.NoError(t, err)
+	return store, root
+}
+
+func TestStore_PutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	store, root := newTestStore(t)
+
+	info, err := store.Put(ctx, "docs/report.txt", strings.NewReader("hello"), 5, "text/plain")
+	require.NoError(t, err)
+	assert.Equal(t, "docs/report.txt", info.Key)
+	assert.Equal(t, int64(5), info.Size)
+	assert.FileExists(t, filepath.Join(root, "docs", "report.txt"))
+
+	stat, err := store.Stat(ctx, "docs/report.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), stat.Size)
+
+	content, info, err := store.Get(ctx, "docs/report.txt")
+	require.NoError(t, err)
+	defer content.Close()
+	assert.Equal(t, int64(5), info.Size)
+	// Содержимое поддерживает Seek для ответов на запросы диапазонов
+	_, err = content.Seek(1, io.SeekStart)
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "ello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "docs/report.txt"))
+	_, err = store.Stat(ctx, "docs/report.txt")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	// Повторное удаление - не ошибка
+	assert.NoError(t, store.Delete(ctx, "docs/report.txt"))
+}
+
+func TestStore_Put_SizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	store, root := newTestStore(t)
+
+	_, err := store.Put(ctx, "short.txt", strings.NewReader("abc"), 5, "")
+	assert.Error(t, err)
+	_, err = store.Stat(ctx, "short.txt")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	// Временный файл недописанной загрузки не остается
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStore_MissingKey(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+
+	_, _, err := store.Get(ctx, "missing.txt")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	_, err = store.Stat(ctx, "missing.txt")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+func TestStore_PathTraversal(t *testing.T) {
+	ctx := context.Background()
+	store, root := newTestStore(t)
+	outside := filepath.Join(filepath.Dir(root), "outside.txt")
+
+	// Ключ не может указывать за пределы root: ".." отсекается корнем хранилища
+	_, err := store.Put(ctx, "../outside.txt", strings.NewReader("x"), 1, "")
+	require.NoError(t, err)
+	assert.NoFileExists(t, outside)
+	assert.FileExists(t, filepath.Join(root, "outside.txt"))
+
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o600))
+	content, _, err := store.Get(ctx, "../../outside.txt")
+	require.NoError(t, err)
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(data))
+
+	require.NoError(t, store.Delete(ctx, "../outside.txt"))
+	assert.FileExists(t, outside)
+
+	for _, key := range []string{"", "/", "..", "../.."} {
+		_, err := store.Put(ctx, key, strings.NewReader("x"), 1, "")
+		assert.Error(t, err, "key %q", key)
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	store, root := newTestStore(t)
+
+	for _, key := range []string{"a/1.txt", "a/2.txt", "b/3.txt"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key), -1, "")
+		require.NoError(t, err)
+	}
+	// Недописанные загрузки не попадают в список
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", ".upload-123"), []byte("partial"), 0o600))
+
+	var keys []string
+	require.NoError(t, store.List(ctx, "a/", func(info storage.ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	}))
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a/1.txt", "a/2.txt"}, keys)
+}
+
+func TestNewStore_EmptyRoot(t *testing.T) {
+	_, err := NewStore("")
+	assert.Error(t, err)
+}
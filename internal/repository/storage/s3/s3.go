@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/repository/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+)
+
+// Размер части multipart-загрузки для потока неизвестной длины (до 10000 частей => до ~160 ГиБ)
+const unknownSizePartSize = 16 << 20
+
+type Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// Store - хранилище файлов в S3-совместимом сервисе (AWS S3, MinIO)
+type Store struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewStore(ctx context.Context, cfg Config) (*Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Создаем бакет, если его нет
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("cannot create bucket: %w", err)
+		}
+	}
+	return &Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (storage.ObjectInfo, error) {
+	opts := minio.PutObjectOptions{ContentType: contentType}
+	if size < 0 {
+		// Без PartSize клиент рассчитывает части под максимальный объект (5 ТиБ) и выделяет буфер ~0.5 ГиБ
+		opts.PartSize = unknownSizePartSize
+	}
+	info, err := s.client.PutObject(ctx, s.bucket, key, r, size, opts)
+	if err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("cannot upload object: %w", err)
+	}
+	return storage.ObjectInfo{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: contentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (s *Store) Get(ctx context.Context, key string) (io.ReadSeekCloser, storage.ObjectInfo, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, storage.ObjectInfo{}, wrapErr(err)
+	}
+	// GetObject ленивый: запрос к серверу уходит только при первом обращении
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, storage.ObjectInfo{}, wrapErr(err)
+	}
+	return obj, objectInfo(stat), nil
+}
+
+func (s *Store) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	stat, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return storage.ObjectInfo{}, wrapErr(err)
+	}
+	return objectInfo(stat), nil
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		if errors.Is(wrapErr(err), storage.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) List(ctx context.Context, prefix string, fn func(storage.ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	// Отмена контекста останавливает горутину листинга, если fn вернула ошибку
+	defer cancel()
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(objectInfo(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func objectInfo(obj minio.ObjectInfo) storage.ObjectInfo {
+	return storage.ObjectInfo{
+		Key:         obj.Key,
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.LastModified,
+	}
+}
+
+func wrapErr(err error) error {
+	if resp := minio.ToErrorResponse(err); resp.Code == "NoSuchKey" {
+		return storage.ErrNotExist
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotExist возвращается, если объекта с таким ключом нет в хранилище
+var ErrNotExist = errors.New("blob does not exist")
+
+// ObjectInfo - метаданные объекта в хранилище
+type ObjectInfo struct {
+	Key         string    // Ключ объекта
+	Size        int64     // Размер в байтах
+	ContentType string    // MIME-тип, если хранилище его поддерживает
+	ModTime     time.Time // Время последнего изменения
+}
+
+// BlobStore - хранилище содержимого файлов. Метаданные документов лежат в postgres,
+// а само содержимое - здесь, под ключом, сохраненным в documents.storage_key
+type BlobStore interface {
+	// Put сохраняет содержимое r под ключом key. size = -1, если размер заранее неизвестен
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error)
+	// Get открывает объект на чтение. Объект поддерживает Seek, чтобы можно было отдавать диапазоны
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error)
+	// Stat возвращает метаданные объекта без чтения содержимого
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete удаляет объект. Удаление несуществующего объекта не считается ошибкой
+	Delete(ctx context.Context, key string) error
+	// List вызывает fn для каждого объекта, ключ которого начинается с prefix
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
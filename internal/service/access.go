@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository"
+)
+
+// AccessPolicy - правила доступа к документам:
+//   - администратор может все;
+//   - владелец может все;
+//   - публичный документ может читать любой пользователь;
+//   - остальным нужен доступ уровня не ниже требуемого: read - чтение, edit - изменение, reshare - выдача доступа.
+//
+// Удалять документ, менять публичность и владельца могут только владелец и администратор
+type AccessPolicy struct {
+	grants repository.Document
+}
+
+func NewAccessPolicy(grants repository.Document) AccessPolicy {
+	return AccessPolicy{grants: grants}
+}
+
+func (p AccessPolicy) CanRead(user models.User, doc models.Document) (bool, error) {
+	if user.IsAdmin || doc.OwnerID == user.ID || doc.Public {
+		return true, nil
+	}
+	return p.hasGrant(user, doc, models.GrantRead)
+}
+
+func (p AccessPolicy) CanWrite(user models.User, doc models.Document) (bool, error) {
+	if user.IsAdmin || doc.OwnerID == user.ID {
+		return true, nil
+	}
+	return p.hasGrant(user, doc, models.GrantEdit)
+}
+
+func (p AccessPolicy) CanShare(user models.User, doc models.Document) (bool, error) {
+	if user.IsAdmin || doc.OwnerID == user.ID {
+		return true, nil
+	}
+	return p.hasGrant(user, doc, models.GrantReshare)
+}
+
+func (p AccessPolicy) CanDelete(user models.User, doc models.Document) (bool, error) {
+	return user.IsAdmin || doc.OwnerID == user.ID, nil
+}
+
+func (p AccessPolicy) hasGrant(user models.User, doc models.Document, required models.GrantLevel) (bool, error) {
+	level, err := p.grants.GetGrantLevel(doc.ID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	return level.Includes(required), nil
+}
+
+// Действия с документом, права на которые проверяет authorize
+type documentAction int
+
+const (
+	actionRead  documentAction = iota // Чтение метаданных и содержимого
+	actionEdit                        // Изменение метаданных и замена содержимого
+	actionShare                       // Просмотр, выдача и отзыв доступов
+	actionOwn                         // Удаление, публичность, передача владения
+)
+
+var actionName = map[documentAction]string{
+	actionRead:  "read",
+	actionEdit:  "edit",
+	actionShare: "share",
+	actionOwn:   "manage",
+}
+
+// authorize - единая проверка прав пользователя на действие с документом по AccessPolicy.
+// Документы в корзине доступны только через операции с корзиной (authorizeTrashed)
+func (d DocumentService) authorize(idUser int, idFile int, action documentAction) (models.Document, error) {
+	return d.authorizeIn(idUser, idFile, action, false)
+}
+
+// authorizeTrashed - проверка прав на действие с документом из корзины
+func (d DocumentService) authorizeTrashed(idUser int, idFile int, action documentAction) (models.Document, error) {
+	return d.authorizeIn(idUser, idFile, action, true)
+}
+
+func (d DocumentService) authorizeIn(idUser int, idFile int, action documentAction, trashed bool) (models.Document, error) {
+	doc, err := d.repo.GetInfo(idFile)
+	if err != nil {
+		return models.Document{}, repoError(err, "document")
+	}
+	user, err := d.users.GetUserById(idUser)
+	if err != nil {
+		return models.Document{}, repoError(err, "user")
+	}
+
+	// Документ, который пользователь не может читать, для него не существует:
+	// иначе по разнице 403 и 404 можно перебирать идентификаторы
+	allowed, err := d.policy.CanRead(user, doc)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if !allowed {
+		return models.Document{}, fmt.Errorf("%w: document not found", ErrNotFound)
+	}
+	if (doc.DeletedAt != nil) != trashed {
+		if trashed {
+			return models.Document{}, fmt.Errorf("%w: document %d is not in trash", ErrNotFound, idFile)
+		}
+		return models.Document{}, fmt.Errorf("%w: document not found", ErrNotFound)
+	}
+
+	switch action {
+	case actionRead:
+	case actionEdit:
+		allowed, err = d.policy.CanWrite(user, doc)
+	case actionShare:
+		allowed, err = d.policy.CanShare(user, doc)
+	default:
+		allowed, err = d.policy.CanDelete(user, doc)
+	}
+	if err != nil {
+		return models.Document{}, err
+	}
+	if !allowed {
+		return models.Document{}, fmt.Errorf("%w: not allowed to %s document %d", ErrForbidden, actionName[action], idFile)
+	}
+	return doc, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/katenester/doc/internal/models"
+	mock_repository "github.com/katenester/doc/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	ownerID   = 1
+	granteeID = 2
+	adminID   = 3
+	docID     = 10
+)
+
+var (
+	owner   = models.User{ID: ownerID, Login: "owner"}
+	grantee = models.User{ID: granteeID, Login: "grantee"}
+	admin   = models.User{ID: adminID, Login: "admin", IsAdmin: true}
+)
+
+func grantLevel(l models.GrantLevel) *models.GrantLevel {
+	return &l
+}
+
+func TestAccessPolicy(t *testing.T) {
+	privateDoc := models.Document{ID: docID, OwnerID: ownerID}
+	publicDoc := models.Document{ID: docID, OwnerID: ownerID, Public: true}
+
+	type check func(p AccessPolicy, user models.User, doc models.Document) (bool, error)
+	var (
+		canRead   check = AccessPolicy.CanRead
+		canWrite  check = AccessPolicy.CanWrite
+		canShare  check = AccessPolicy.CanShare
+		canDelete check = AccessPolicy.CanDelete
+	)
+
+	tests := []struct {
+		name  string
+		check check
+		user  models.User
+		doc   models.Document
+		grant *models.GrantLevel // Уровень доступа из репозитория (nil - репозиторий не должен вызываться)
+		want  bool
+	}{
+		{"owner reads own private document", canRead, owner, privateDoc, nil, true},
+		{"owner writes own document", canWrite, owner, privateDoc, nil, true},
+		{"owner shares own document", canShare, owner, privateDoc, nil, true},
+		{"owner deletes own document", canDelete, owner, privateDoc, nil, true},
+
+		{"admin reads private document", canRead, admin, privateDoc, nil, true},
+		{"admin writes private document", canWrite, admin, privateDoc, nil, true},
+		{"admin shares private document", canShare, admin, privateDoc, nil, true},
+		{"admin deletes private document", canDelete, admin, privateDoc, nil, true},
+
+		{"anyone reads public document", canRead, grantee, publicDoc, nil, true},
+		{"public document is not writable", canWrite, grantee, publicDoc, grantLevel(""), false},
+		{"public document is not deletable", canDelete, grantee, publicDoc, nil, false},
+
+		{"no grant cannot read", canRead, grantee, privateDoc, grantLevel(""), false},
+		{"no grant cannot write", canWrite, grantee, privateDoc, grantLevel(""), false},
+		{"no grant cannot share", canShare, grantee, privateDoc, grantLevel(""), false},
+
+		{"read grant reads", canRead, grantee, privateDoc, grantLevel(models.GrantRead), true},
+		{"read grant cannot write", canWrite, grantee, privateDoc, grantLevel(models.GrantRead), false},
+		{"read grant cannot share", canShare, grantee, privateDoc, grantLevel(models.GrantRead), false},
+
+		{"edit grant reads", canRead, grantee, privateDoc, grantLevel(models.GrantEdit), true},
+		{"edit grant writes", canWrite, grantee, privateDoc, grantLevel(models.GrantEdit), true},
+		{"edit grant cannot share", canShare, grantee, privateDoc, grantLevel(models.GrantEdit), false},
+
+		{"reshare grant writes", canWrite, grantee, privateDoc, grantLevel(models.GrantReshare), true},
+		{"reshare grant shares", canShare, grantee, privateDoc, grantLevel(models.GrantReshare), true},
+		{"reshare grant cannot delete", canDelete, grantee, privateDoc, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock_repository.NewMockDocument(ctrl)
+			if tt.grant != nil {
+				repo.EXPECT().GetGrantLevel(tt.doc.ID, tt.user.ID).Return(*tt.grant, nil)
+			}
+
+			got, err := tt.check(NewAccessPolicy(repo), tt.user, tt.doc)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAccessPolicy_GrantError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoErr := errors.New("connection refused")
+	repo := mock_repository.NewMockDocument(ctrl)
+	repo.EXPECT().GetGrantLevel(docID, granteeID).Return(models.GrantLevel(""), repoErr)
+
+	allowed, err := NewAccessPolicy(repo).CanRead(grantee, models.Document{ID: docID, OwnerID: ownerID})
+	assert.ErrorIs(t, err, repoErr)
+	assert.False(t, allowed)
+}
+
+func TestDocumentService_GetInfo(t *testing.T) {
+	privateDoc := models.Document{ID: docID, OwnerID: ownerID, Name: "report.pdf"}
+
+	tests := []struct {
+		name    string
+		user    models.User
+		grant   *models.GrantLevel
+		wantErr error
+	}{
+		{name: "owner of private document", user: owner},
+		{name: "admin", user: admin},
+		{name: "grantee", user: grantee, grant: grantLevel(models.GrantRead)},
+		{name: "stranger", user: grantee, grant: grantLevel(""), wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			docs := mock_repository.NewMockDocument(ctrl)
+			users := mock_repository.NewMockAuthorization(ctrl)
+			docs.EXPECT().GetInfo(docID).Return(privateDoc, nil)
+			users.EXPECT().GetUserById(tt.user.ID).Return(tt.user, nil)
+			if tt.grant != nil {
+				docs.EXPECT().GetGrantLevel(docID, tt.user.ID).Return(*tt.grant, nil)
+			}
+
+			doc, err := NewDocumentService(docs, users, 0).GetInfo(tt.user.ID, docID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, privateDoc, doc)
+		})
+	}
+}
+
+// 403 получает только тот, кто видит документ; для остальных документа нет
+func TestDocumentService_ForbiddenOnlyForReaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		grant   models.GrantLevel
+		wantErr error
+	}{
+		{name: "reader cannot edit", grant: models.GrantRead, wantErr: ErrForbidden},
+		{name: "stranger does not see document", grant: "", wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			docs := mock_repository.NewMockDocument(ctrl)
+			users := mock_repository.NewMockAuthorization(ctrl)
+			docs.EXPECT().GetInfo(docID).Return(models.Document{ID: docID, OwnerID: ownerID}, nil)
+			users.EXPECT().GetUserById(granteeID).Return(grantee, nil)
+			docs.EXPECT().GetGrantLevel(docID, granteeID).Return(tt.grant, nil).AnyTimes()
+
+			_, err := NewDocumentService(docs, users, 0).UpdateInfo(granteeID, docID, DocumentPatch{}, "")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AdminKey - ключ администратора для регистрации пользователей. Хранится только SHA-256 ключа.
+// Ключей может быть несколько: при ротации новый ключ добавляется рядом со старым,
+// а старому задается NotAfter, после которого он перестает приниматься
+type AdminKey struct {
+	ID       string    // Имя ключа для логов
+	SHA256   string    // SHA-256 ключа в hex
+	NotAfter time.Time // Срок действия (нулевое значение - бессрочно)
+}
+
+var errInvalidAdminToken = fmt.Errorf("%w: invalid admin token", ErrUnauthorized)
+
+// AuthorizeAdmin проверяет, что token - действующий ключ администратора или токен сессии администратора.
+// Возвращает имя ключа или логин администратора для аудита
+func (s *AuthService) AuthorizeAdmin(token string) (string, error) {
+	if token == "" {
+		return "", errInvalidAdminToken
+	}
+	if key, ok := s.matchAdminKey(token, time.Now()); ok {
+		return "key:" + key.ID, nil
+	}
+
+	// Не ключ - возможно, токен сессии пользователя с флагом is_admin
+	userID, err := s.GetUserId(token)
+	if errors.Is(err, ErrUnauthorized) {
+		return "", errInvalidAdminToken
+	}
+	if err != nil {
+		return "", err
+	}
+	user, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return "", repoError(err, "user")
+	}
+	if !user.IsAdmin {
+		return "", fmt.Errorf("%w: user %s is not an administrator", ErrForbidden, user.Login)
+	}
+	return "user:" + user.Login, nil
+}
+
+func (s *AuthService) matchAdminKey(token string, now time.Time) (AdminKey, bool) {
+	sum := sha256.Sum256([]byte(token))
+	hash := hex.EncodeToString(sum[:])
+	var (
+		matched AdminKey
+		found   bool
+	)
+	// Проверяем все ключи без раннего выхода, чтобы время ответа не зависело от позиции ключа
+	for _, key := range s.adminKeys {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(key.SHA256)) == 1 &&
+			(key.NotAfter.IsZero() || now.Before(key.NotAfter)) {
+			matched, found = key, true
+		}
+	}
+	return matched, found
+}
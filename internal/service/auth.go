@@ -1,40 +1,47 @@
 package service
 
 import (
-	"crypto/sha1"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/katenester/doc/internal/models"
 	"github.com/katenester/doc/internal/repository"
+	"github.com/sirupsen/logrus"
 	"regexp"
 	"time"
 )
 
-const (
-	salt       = "sfsgGhJjJJHgFRdehYgu"
-	signingKey = "qrkjk#4#%35FSFJlja#4353KSFjH" // Ключ подписи
-	tokenTTL   = 12 * time.Hour
-)
+// Длина токена сессии в байтах (до кодирования в base64)
+const tokenLength = 32
+
+// Одна ошибка для неизвестного логина и неверного пароля, чтобы не раскрывать существование логина
+var errInvalidCredentials = fmt.Errorf("%w: invalid login or password", ErrUnauthorized)
 
-type tokenClaims struct {
-	jwt.StandardClaims
-	UserId int `json:"user_id"`
-}
 type AuthService struct {
-	repo repository.Authorization
+	repo       repository.Authorization
+	sessionTTL time.Duration
+	adminKeys  []AdminKey
 }
 
-func NewAuthService(repo repository.Authorization) *AuthService {
-	return &AuthService{repo}
+func NewAuthService(repo repository.Authorization, sessionTTL time.Duration, adminKeys []AdminKey) *AuthService {
+	return &AuthService{repo: repo, sessionTTL: sessionTTL, adminKeys: adminKeys}
 }
 
 func (s *AuthService) CreateUser(user models.User) error {
 	if validateLogin(user.Login) && validatePassword(user.Password) {
-		user.Password = generatePasswordHash(user.Password)
-		return s.repo.CreateUser(user)
+		hash, err := generatePasswordHash(user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = hash
+		return repoError(s.repo.CreateUser(user), "user")
 	} else {
-		return errors.New("Invalid username or password")
+		return fmt.Errorf("%w: invalid username or password", ErrInvalidParams)
 	}
 }
 func validatePassword(password string) bool {
@@ -43,9 +50,13 @@ func validatePassword(password string) bool {
 		return false
 	}
 	// Минимум 1 заглавная и 1 строчная буква, минимум 1 цифра и 1 спецсимвол
-	regex := `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[^\w\d]).{8,}$`
-	match, _ := regexp.MatchString(regex, password)
-	return match
+	// (regexp в Go не поддерживает lookahead, поэтому каждое условие проверяется отдельно)
+	for _, regex := range []string{`[a-z]`, `[A-Z]`, `\d`, `[^\w\d]`} {
+		if match, _ := regexp.MatchString(regex, password); !match {
+			return false
+		}
+	}
+	return true
 }
 
 // Функция для валидации логина
@@ -55,56 +66,108 @@ func validateLogin(login string) bool {
 	match, _ := regexp.MatchString(regex, login)
 	return match
 }
-func generatePasswordHash(password string) string {
-	hash := sha1.New()
-	hash.Write([]byte(password))
-	return fmt.Sprintf("%x", hash.Sum([]byte(salt)))
-}
+
+// GetUser проверяет логин и пароль и возвращает ID пользователя.
+// Хеш устаревшего формата или со слабыми параметрами пересчитывается прозрачно для пользователя
 func (s *AuthService) GetUser(user models.User) (int, error) {
-	user.Password = generatePasswordHash(user.Password)
-	return s.repo.GetUser(user)
+	stored, err := s.repo.GetUserByLogin(user.Login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errInvalidCredentials
+	}
+	if err != nil {
+		return 0, err
+	}
+	ok, needsRehash, err := verifyPassword(user.Password, stored.Password)
+	if err != nil {
+		return 0, fmt.Errorf("cannot verify password: %v", err)
+	}
+	if !ok {
+		return 0, errInvalidCredentials
+	}
+	if stored.Disabled {
+		return 0, fmt.Errorf("%w: account is disabled", ErrForbidden)
+	}
+	if needsRehash {
+		// Ошибка пересчета не мешает входу: попробуем снова при следующем входе
+		if hash, err := generatePasswordHash(user.Password); err == nil {
+			if err := s.repo.UpdatePasswordHash(stored.ID, hash); err != nil {
+				logrus.Warnf("cannot rehash password of user %d: %s", stored.ID, err.Error())
+			}
+		}
+	}
+	return stored.ID, nil
+}
+func (s *AuthService) GetUserByLogin(login string) (models.User, error) {
+	user, err := s.repo.GetUserByLogin(login)
+	return user, repoError(err, "user "+login)
+}
+
+// GenerateToken проверяет логин и пароль и открывает новую сессию.
+// Токен - случайная непрозрачная строка; в базе хранится только ее хеш
+func (s *AuthService) GenerateToken(user models.User, client models.SessionClient) (string, error) {
+	userID, err := s.GetUser(user)
+	if err != nil {
+		return "", err
+	}
+	raw := make([]byte, tokenLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("cannot generate token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	if err := s.SaveToken(userID, token, client); err != nil {
+		return "", fmt.Errorf("cannot save session: %v", err)
+	}
+	return token, nil
 }
 func (s *AuthService) GetUserId(token string) (int, error) {
-	return s.repo.GetUserId(token)
+	userID, err := s.repo.GetUserId(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: session not found or expired", ErrUnauthorized)
+	}
+	return userID, err
 }
-func (s *AuthService) SaveToken(userID int, token string) error {
-	return s.repo.SaveToken(userID, token)
+func (s *AuthService) SaveToken(userID int, token string, client models.SessionClient) error {
+	return s.repo.SaveToken(userID, hashToken(token), s.sessionTTL, client)
 }
 func (s *AuthService) DeleteToken(token string) error {
-	return s.repo.DeleteToken(token)
+	return s.repo.DeleteToken(hashToken(token))
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего.
+// Все сессии, кроме текущей (token), завершаются
+func (s *AuthService) ChangePassword(userID int, token string, oldPassword string, newPassword string) error {
+	stored, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return repoError(err, "user")
+	}
+	ok, _, err := verifyPassword(oldPassword, stored.Password)
+	if err != nil {
+		return fmt.Errorf("cannot verify password: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: current password is incorrect", ErrForbidden)
+	}
+	if !validatePassword(newPassword) {
+		return fmt.Errorf("%w: new password does not meet requirements", ErrInvalidParams)
+	}
+	hash, err := generatePasswordHash(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.repo.ChangePasswordHash(context.Background(), userID, hash, hashToken(token))
+}
+
+// ListSessions возвращает активные сессии пользователя, текущая (token) помечена
+func (s *AuthService) ListSessions(userID int, token string) ([]models.Session, error) {
+	return s.repo.ListSessions(userID, hashToken(token))
+}
+
+func (s *AuthService) RevokeSession(userID int, sessionID int) error {
+	return repoError(s.repo.DeleteSession(userID, sessionID), "session")
 }
 
-//func (s *AuthService) GenerateToken(username, password string) (string, error) {
-//	user, err := s.repo.GetUser(username, generatePasswordHash(password))
-//	if err != nil {
-//		return "", err
-//	}
-//	// Если пользователь существует => генерируем токен
-//	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-//		jwt.StandardClaims{
-//			// Дедлайн валидности токена
-//			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
-//			// Время создания токена
-//			IssuedAt: time.Now().Unix(),
-//		},
-//		user.Id,
-//	})
-//	return token.SignedString([]byte(signingKey))
-//}
-//
-//func (s *AuthService) ParseToken(accessToken string) (int, error) {
-//	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-//		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-//			return nil, errors.New("invalid signature method")
-//		}
-//		return []byte(signingKey), nil
-//	})
-//	if err != nil {
-//		return 0, err
-//	}
-//	claims, ok := token.Claims.(*tokenClaims)
-//	if !ok {
-//		return 0, errors.New("token claims is not of type *tokenClaims")
-//	}
-//	return claims.UserId, nil
-//}
+// hashToken - в таблице sessions хранится SHA-256 токена, чтобы утечка базы не давала готовых сессий
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
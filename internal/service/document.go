@@ -1,28 +1,85 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"github.com/katenester/doc/internal/models"
 	"github.com/katenester/doc/internal/repository"
-	"mime/multipart"
+	"io"
 )
 
+// Максимальный размер страницы в списке документов
+const maxListLimit = 100
+
 type DocumentService struct {
-	repo repository.Document
+	repo      repository.Document
+	users     repository.Authorization
+	policy    AccessPolicy
+	retention int // Сколько последних версий хранить для документа (0 - без ограничения)
 }
 
-func NewDocumentService(repo repository.Document) *DocumentService {
-	return &DocumentService{repo: repo}
+func NewDocumentService(repo repository.Document, users repository.Authorization, retention int) *DocumentService {
+	return &DocumentService{repo: repo, users: users, policy: NewAccessPolicy(repo), retention: retention}
 }
 
-func (d DocumentService) Create(fileHeader *multipart.FileHeader, doc models.Document, users []models.User) error {
-	return d.repo.Create(fileHeader, doc, users)
+func (d DocumentService) Create(doc models.Document, content io.Reader, users []models.User) (int, error) {
+	if doc.Name == "" {
+		return 0, fmt.Errorf("%w: document name is required", ErrInvalidParams)
+	}
+	id, err := d.repo.Create(context.Background(), doc, content, users)
+	return id, repoError(err, "document")
 }
-func (d DocumentService) GetFile(idUser int, idFile int) (*multipart.FileHeader, models.Document, error) {
-	return d.repo.GetFile(idUser, idFile)
+func (d DocumentService) GetInfo(idUser int, idFile int) (models.Document, error) {
+	return d.authorize(idUser, idFile, actionRead)
 }
-func (d DocumentService) GetAllFile(idUser int) ([]*multipart.FileHeader, []models.Document, error) {
-	return d.repo.GetAllFile(idUser)
+
+// GetFile возвращает метаданные и поток содержимого документа (nil для документа без файла)
+func (d DocumentService) GetFile(idUser int, idFile int) (io.ReadSeekCloser, models.Document, error) {
+	doc, err := d.authorize(idUser, idFile, actionRead)
+	if err != nil {
+		return nil, models.Document{}, err
+	}
+	if !doc.File {
+		return nil, doc, nil
+	}
+	content, err := d.repo.OpenFile(doc.StorageKey)
+	if err != nil {
+		return nil, models.Document{}, err
+	}
+	return content, doc, nil
 }
+
+// GetAllFile возвращает страницу списка документов и позицию для запроса следующей страницы
+// (nil, если страница последняя)
+func (d DocumentService) GetAllFile(filter models.DocumentFilter) ([]models.Document, *models.DocumentCursor, error) {
+	if filter.Limit < 1 || filter.Limit > maxListLimit {
+		return nil, nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidParams, maxListLimit)
+	}
+	if filter.Value != "" && filter.Key == "" {
+		return nil, nil, fmt.Errorf("%w: value filter requires key", ErrInvalidParams)
+	}
+
+	// Запрашиваем на один документ больше, чтобы понять, есть ли следующая страница
+	page := filter
+	page.Limit = filter.Limit + 1
+	documents, err := d.repo.GetAllFile(page)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(documents) <= filter.Limit {
+		return documents, nil, nil
+	}
+
+	documents = documents[:filter.Limit]
+	last := documents[len(documents)-1]
+	return documents, &models.DocumentCursor{Name: last.Name, CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+// DeleteFile перемещает документ в корзину: он пропадает из списков и становится недоступен,
+// но может быть восстановлен до окончательного удаления
 func (d DocumentService) DeleteFile(idUser int, idFile int) error {
-	return d.repo.DeleteFile(idUser, idFile)
+	if _, err := d.authorize(idUser, idFile, actionOwn); err != nil {
+		return err
+	}
+	return repoError(d.repo.TrashFile(idFile), "document")
 }
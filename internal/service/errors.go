@@ -0,0 +1,38 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/repository"
+)
+
+// Доменные ошибки. Сервисы оборачивают их с пояснением через fmt.Errorf("%w: ...", ErrX),
+// транспорт определяет по ним HTTP-код через errors.Is
+var (
+	ErrInvalidParams = errors.New("invalid parameters")
+	ErrUnauthorized  = errors.New("not authorized")
+	ErrForbidden     = errors.New("access denied")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrGone          = errors.New("no longer available")
+	ErrPrecondition  = errors.New("precondition failed")
+)
+
+// repoError - перевод ошибок репозитория в доменные. what - о каком объекте речь ("document", "user")
+func repoError(err error, what string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %s not found", ErrNotFound, what)
+	case errors.Is(err, repository.ErrAccessDenied):
+		return fmt.Errorf("%w: no access to %s", ErrForbidden, what)
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %s already exists", ErrConflict, what)
+	case errors.Is(err, repository.ErrModified):
+		return fmt.Errorf("%w: %s was modified by another request", ErrPrecondition, what)
+	default:
+		return err
+	}
+}
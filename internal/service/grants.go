@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository"
+)
+
+// findUser - пользователь по логину для выдачи доступа или изменения состава группы.
+// Вызывается только после проверки прав: иначе по ответам можно перебирать логины
+func findUser(users repository.Authorization, login string) (models.User, error) {
+	user, err := users.GetUserByLogin(login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%w: user %s not found", ErrInvalidParams, login)
+	}
+	return user, err
+}
+
+// GetGrants возвращает документ и список пользователей, которым выдан доступ к нему
+func (d DocumentService) GetGrants(idUser int, idFile int) (models.Document, []models.DocumentGrant, error) {
+	doc, err := d.authorize(idUser, idFile, actionShare)
+	if err != nil {
+		return models.Document{}, nil, err
+	}
+	grants, err := d.repo.GetGrants(idFile)
+	if err != nil {
+		return models.Document{}, nil, err
+	}
+	return doc, grants, nil
+}
+
+// AddGrant выдает пользователю доступ уровня level или меняет уровень уже выданного доступа
+func (d DocumentService) AddGrant(idUser int, idFile int, login string, level models.GrantLevel) (models.DocumentGrant, error) {
+	if !level.Valid() {
+		return models.DocumentGrant{}, fmt.Errorf("%w: unknown grant level %q", ErrInvalidParams, level)
+	}
+	doc, err := d.authorize(idUser, idFile, actionShare)
+	if err != nil {
+		return models.DocumentGrant{}, err
+	}
+	grantee, err := findUser(d.users, login)
+	if err != nil {
+		return models.DocumentGrant{}, err
+	}
+	if grantee.ID == doc.OwnerID {
+		return models.DocumentGrant{}, fmt.Errorf("%w: the owner already has access", ErrInvalidParams)
+	}
+	if grantee.ID == idUser {
+		return models.DocumentGrant{}, fmt.Errorf("%w: cannot change own access", ErrInvalidParams)
+	}
+	grant, err := d.repo.AddGrant(idFile, grantee.ID, level)
+	return grant, repoError(err, "grant")
+}
+
+func (d DocumentService) RemoveGrant(idUser int, idFile int, login string) error {
+	if _, err := d.authorize(idUser, idFile, actionShare); err != nil {
+		return err
+	}
+	grantee, err := findUser(d.users, login)
+	if err != nil {
+		return err
+	}
+	return repoError(d.repo.RemoveGrant(idFile, grantee.ID), "grant for "+grantee.Login)
+}
+
+func (d DocumentService) SetPublic(idUser int, idFile int, public bool) error {
+	if _, err := d.authorize(idUser, idFile, actionOwn); err != nil {
+		return err
+	}
+	return repoError(d.repo.SetPublic(idFile, public), "document")
+}
+
+// TransferOwner передает документ другому пользователю. Прежний владелец теряет доступ к документу
+func (d DocumentService) TransferOwner(idUser int, idFile int, login string) error {
+	doc, err := d.authorize(idUser, idFile, actionOwn)
+	if err != nil {
+		return err
+	}
+	newOwner, err := findUser(d.users, login)
+	if err != nil {
+		return err
+	}
+	if newOwner.ID == doc.OwnerID {
+		return fmt.Errorf("%w: user %s already owns the document", ErrInvalidParams, newOwner.Login)
+	}
+	return repoError(d.repo.SetOwner(context.Background(), idFile, newOwner.ID), "document")
+}
+
+// GetGroupGrants возвращает список доступов групп к документу
+func (d DocumentService) GetGroupGrants(idUser int, idFile int) ([]models.DocumentGroupGrant, error) {
+	if _, err := d.authorize(idUser, idFile, actionShare); err != nil {
+		return nil, err
+	}
+	return d.repo.GetGroupGrants(idFile)
+}
+
+// AddGroupGrant выдает группе доступ уровня level или меняет уровень уже выданного доступа.
+// Выдать доступ можно только группе, в которой idUser состоит; для чужой группы - ErrNotFound
+func (d DocumentService) AddGroupGrant(idUser int, idFile int, idGroup int, level models.GrantLevel) (models.DocumentGroupGrant, error) {
+	if !level.Valid() {
+		return models.DocumentGroupGrant{}, fmt.Errorf("%w: unknown grant level %q", ErrInvalidParams, level)
+	}
+	if _, err := d.authorize(idUser, idFile, actionShare); err != nil {
+		return models.DocumentGroupGrant{}, err
+	}
+	grant, err := d.repo.AddGroupGrant(idFile, idGroup, idUser, level)
+	return grant, repoError(err, "group")
+}
+
+func (d DocumentService) RemoveGroupGrant(idUser int, idFile int, idGroup int) error {
+	if _, err := d.authorize(idUser, idFile, actionShare); err != nil {
+		return err
+	}
+	return repoError(d.repo.RemoveGroupGrant(idFile, idGroup), "group grant")
+}
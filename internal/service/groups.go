@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository"
+	"strings"
+)
+
+type GroupsService struct {
+	repo  repository.Groups
+	users repository.Authorization
+}
+
+func NewGroupsService(repo repository.Groups, users repository.Authorization) *GroupsService {
+	return &GroupsService{repo: repo, users: users}
+}
+
+// CreateGroup создает группу, idUser становится ее владельцем
+func (s *GroupsService) CreateGroup(idUser int, name string) (models.Group, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Group{}, fmt.Errorf("%w: group name is required", ErrInvalidParams)
+	}
+	group, err := s.repo.CreateGroup(context.Background(), name, idUser)
+	return group, repoError(err, "group "+name)
+}
+
+// ListGroups возвращает группы, в которых состоит пользователь
+func (s *GroupsService) ListGroups(idUser int) ([]models.Group, error) {
+	return s.repo.ListGroups(idUser)
+}
+
+// GetMembers возвращает участников группы. Список видят только участники
+func (s *GroupsService) GetMembers(idUser int, groupID int) ([]models.GroupMember, error) {
+	if _, err := s.requireRole(idUser, groupID, models.GroupMemberRole); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(groupID)
+}
+
+// SetMember добавляет участника или меняет его роль. Участников добавляет менеджер,
+// назначать менеджеров и владельцев, а также менять их роли может только владелец
+func (s *GroupsService) SetMember(idUser int, groupID int, login string, role models.GroupRole) error {
+	if !role.Valid() {
+		return fmt.Errorf("%w: unknown group role %q", ErrInvalidParams, role)
+	}
+	myRole, err := s.requireRole(idUser, groupID, models.GroupManager)
+	if err != nil {
+		return err
+	}
+	member, err := findUser(s.users, login)
+	if err != nil {
+		return err
+	}
+	current, err := s.repo.GetMemberRole(groupID, member.ID)
+	if err != nil {
+		return repoError(err, "group")
+	}
+	if (role != models.GroupMemberRole || current.Includes(models.GroupManager)) && myRole != models.GroupOwner {
+		return fmt.Errorf("%w: only the group owner can manage roles", ErrForbidden)
+	}
+	if current == models.GroupOwner && role != models.GroupOwner {
+		if err := s.keepOwner(groupID, member.ID); err != nil {
+			return err
+		}
+	}
+	return repoError(s.repo.SetMember(groupID, member.ID, role), "group")
+}
+
+// RemoveMember удаляет участника из группы. Любой участник может выйти из группы сам,
+// менеджер удаляет участников, владелец - кого угодно. Последнего владельца удалить нельзя
+func (s *GroupsService) RemoveMember(idUser int, groupID int, login string) error {
+	if _, err := s.requireRole(idUser, groupID, models.GroupMemberRole); err != nil {
+		return err
+	}
+	member, err := findUser(s.users, login)
+	if err != nil {
+		return err
+	}
+	current, err := s.repo.GetMemberRole(groupID, member.ID)
+	if err != nil {
+		return repoError(err, "group")
+	}
+	if member.ID != idUser {
+		required := models.GroupManager
+		if current.Includes(models.GroupManager) {
+			required = models.GroupOwner
+		}
+		if _, err := s.requireRole(idUser, groupID, required); err != nil {
+			return err
+		}
+	}
+	if current == models.GroupOwner {
+		if err := s.keepOwner(groupID, member.ID); err != nil {
+			return err
+		}
+	}
+	return repoError(s.repo.RemoveMember(groupID, member.ID), "group member "+member.Login)
+}
+
+// DeleteGroup удаляет группу и выданные ей доступы. Доступно только владельцу
+func (s *GroupsService) DeleteGroup(idUser int, groupID int) error {
+	if _, err := s.requireRole(idUser, groupID, models.GroupOwner); err != nil {
+		return err
+	}
+	return repoError(s.repo.DeleteGroup(groupID), "group")
+}
+
+// requireRole проверяет, что роль пользователя в группе не ниже required, и возвращает ее
+func (s *GroupsService) requireRole(idUser int, groupID int, required models.GroupRole) (models.GroupRole, error) {
+	role, err := s.repo.GetMemberRole(groupID, idUser)
+	if err != nil {
+		return "", repoError(err, "group")
+	}
+	if !role.Includes(required) {
+		return "", fmt.Errorf("%w: %s role in group %d is required", ErrForbidden, required, groupID)
+	}
+	return role, nil
+}
+
+// keepOwner проверяет, что после ухода владельца ownerID в группе останется другой владелец
+func (s *GroupsService) keepOwner(groupID int, ownerID int) error {
+	members, err := s.repo.ListMembers(groupID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.Role == models.GroupOwner && m.UserID != ownerID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: group must have at least one owner", ErrInvalidParams)
+}
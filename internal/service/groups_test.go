@@ -0,0 +1,138 @@
+package service
+
+import (
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/katenester/doc/internal/models"
+	mock_repository "github.com/katenester/doc/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGroupsService_SetMember(t *testing.T) {
+	const groupID = 5
+	member := models.User{ID: granteeID, Login: "grantee"}
+
+	tests := []struct {
+		name    string
+		myRole  models.GroupRole // Роль пользователя, который меняет состав группы
+		current models.GroupRole // Текущая роль изменяемого участника ("" - не в группе)
+		role    models.GroupRole // Новая роль
+		owners  int              // Владельцев в группе, кроме изменяемого участника
+		wantErr error
+	}{
+		{name: "manager adds member", myRole: models.GroupManager, role: models.GroupMemberRole},
+		{name: "manager cannot add manager", myRole: models.GroupManager, role: models.GroupManager, wantErr: ErrForbidden},
+		{name: "manager cannot demote manager", myRole: models.GroupManager, current: models.GroupManager, role: models.GroupMemberRole, wantErr: ErrForbidden},
+		{name: "member cannot add members", myRole: models.GroupMemberRole, role: models.GroupMemberRole, wantErr: ErrForbidden},
+		{name: "stranger cannot add members", myRole: "", role: models.GroupMemberRole, wantErr: ErrForbidden},
+		{name: "owner adds owner", myRole: models.GroupOwner, role: models.GroupOwner},
+		{name: "owner demotes another owner", myRole: models.GroupOwner, current: models.GroupOwner, role: models.GroupManager, owners: 1},
+		{name: "last owner cannot be demoted", myRole: models.GroupOwner, current: models.GroupOwner, role: models.GroupManager, wantErr: ErrInvalidParams},
+		{name: "unknown role", myRole: models.GroupOwner, role: "admin", wantErr: ErrInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock_repository.NewMockGroups(ctrl)
+			users := mock_repository.NewMockAuthorization(ctrl)
+			users.EXPECT().GetUserByLogin(member.Login).Return(member, nil).AnyTimes()
+			repo.EXPECT().GetMemberRole(groupID, ownerID).Return(tt.myRole, nil).AnyTimes()
+			repo.EXPECT().GetMemberRole(groupID, member.ID).Return(tt.current, nil).AnyTimes()
+			members := []models.GroupMember{{GroupID: groupID, UserID: member.ID, Role: tt.current}}
+			for i := 0; i < tt.owners; i++ {
+				members = append(members, models.GroupMember{GroupID: groupID, UserID: 100 + i, Role: models.GroupOwner})
+			}
+			repo.EXPECT().ListMembers(groupID).Return(members, nil).AnyTimes()
+			if tt.wantErr == nil {
+				repo.EXPECT().SetMember(groupID, member.ID, tt.role).Return(nil)
+			}
+
+			err := NewGroupsService(repo, users).SetMember(ownerID, groupID, member.Login, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDocumentService_AddGroupGrant_ForeignGroup(t *testing.T) {
+	const groupID = 5
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	docs := mock_repository.NewMockDocument(ctrl)
+	users := mock_repository.NewMockAuthorization(ctrl)
+	docs.EXPECT().GetInfo(docID).Return(models.Document{ID: docID, OwnerID: ownerID}, nil)
+	users.EXPECT().GetUserById(ownerID).Return(owner, nil).AnyTimes()
+	// Владелец документа не состоит в группе: репозиторий не находит ее
+	docs.EXPECT().AddGroupGrant(docID, groupID, ownerID, models.GrantRead).Return(models.DocumentGroupGrant{}, sql.ErrNoRows)
+
+	_, err := NewDocumentService(docs, users, 0).AddGroupGrant(ownerID, docID, groupID, models.GrantRead)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Логин проверяется только после проверки прав: посторонний не должен отличать
+// несуществующий логин от существующего по ответу
+func TestLoginResolvedAfterAuthorization(t *testing.T) {
+	const groupID = 5
+	tests := []struct {
+		name    string
+		call    func(d *DocumentService, g *GroupsService) error
+		wantErr error
+	}{
+		{
+			name: "add grant without share access",
+			call: func(d *DocumentService, _ *GroupsService) error {
+				_, err := d.AddGrant(granteeID, docID, "nobody", models.GrantRead)
+				return err
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "remove grant without share access",
+			call:    func(d *DocumentService, _ *GroupsService) error { return d.RemoveGrant(granteeID, docID, "nobody") },
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "transfer document of another owner",
+			call:    func(d *DocumentService, _ *GroupsService) error { return d.TransferOwner(granteeID, docID, "nobody") },
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "add member to foreign group",
+			call: func(_ *DocumentService, g *GroupsService) error {
+				return g.SetMember(granteeID, groupID, "nobody", models.GroupMemberRole)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "remove member from foreign group",
+			call:    func(_ *DocumentService, g *GroupsService) error { return g.RemoveMember(granteeID, groupID, "nobody") },
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			docs := mock_repository.NewMockDocument(ctrl)
+			groups := mock_repository.NewMockGroups(ctrl)
+			users := mock_repository.NewMockAuthorization(ctrl)
+			docs.EXPECT().GetInfo(docID).Return(models.Document{ID: docID, OwnerID: ownerID}, nil).AnyTimes()
+			docs.EXPECT().GetGrantLevel(docID, granteeID).Return(models.GrantRead, nil).AnyTimes()
+			groups.EXPECT().GetMemberRole(groupID, granteeID).Return(models.GroupRole(""), sql.ErrNoRows).AnyTimes()
+			users.EXPECT().GetUserById(granteeID).Return(grantee, nil).AnyTimes()
+			users.EXPECT().GetUserByLogin(gomock.Any()).Times(0)
+
+			err := tt.call(NewDocumentService(docs, users, 0), NewGroupsService(groups, users))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository/storage"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Префикс ключей, под которые переносятся файлы-сироты при карантине.
+// Файлы в карантине не проверяются и не считаются сиротами повторно
+const quarantinePrefix = "quarantine/"
+
+// OrphanAction - что делать с файлами, на которые не ссылается ни документ, ни версия, ни журнал
+type OrphanAction string
+
+const (
+	OrphanReport     OrphanAction = "report"     // Только сообщить
+	OrphanQuarantine OrphanAction = "quarantine" // Перенести под quarantinePrefix
+	OrphanDelete     OrphanAction = "delete"     // Удалить
+)
+
+func (a OrphanAction) Valid() bool {
+	return a == OrphanReport || a == OrphanQuarantine || a == OrphanDelete
+}
+
+// IntegrityOptions - параметры проверки хранилища
+type IntegrityOptions struct {
+	VerifyHashes bool          // Пересчитывать SHA-256 файлов (читает все содержимое хранилища)
+	Grace        time.Duration // Сиротами считаются только файлы старше Grace: более новые могут еще сохраняться
+	Orphans      OrphanAction  // Действие с сиротами старше Grace
+}
+
+// IntegrityReport - результат проверки хранилища
+type IntegrityReport struct {
+	Checked     int                 // Проверено файлов, на которые есть ссылки
+	Missing     []models.StorageRef // Ссылки на отсутствующие файлы
+	Corrupted   []models.StorageRef // Файлы, размер или хеш которых не совпадает с записанным
+	Orphaned    []string            // Файлы без ссылок старше Grace
+	Quarantined []string            // Сироты, перенесенные в карантин
+	Deleted     []string            // Удаленные сироты
+}
+
+// CheckIntegrity сверяет ссылки на файлы (документы, версии, журнал) с содержимым хранилища:
+// находит отсутствующие и поврежденные файлы, а также сирот, с которыми поступает по opts.Orphans.
+// Записи в базе не изменяются
+func (s *RepairService) CheckIntegrity(ctx context.Context, opts IntegrityOptions) (IntegrityReport, error) {
+	var report IntegrityReport
+	if opts.Orphans == "" {
+		opts.Orphans = OrphanReport
+	}
+	if !opts.Orphans.Valid() {
+		return report, fmt.Errorf("%w: unknown orphan action %q", ErrInvalidParams, opts.Orphans)
+	}
+
+	blobs, err := s.listBlobs(ctx)
+	if err != nil {
+		return report, err
+	}
+	refs, err := s.repo.ListStorageRefs()
+	if err != nil {
+		return report, err
+	}
+
+	// Один файл может быть и текущим содержимым, и версией: проверяем его один раз,
+	// а в отчет попадают все ссылки на него
+	byKey := make(map[string][]models.StorageRef)
+	var keys []string
+	for _, ref := range refs {
+		if _, ok := byKey[ref.StorageKey]; !ok {
+			keys = append(keys, ref.StorageKey)
+		}
+		byKey[ref.StorageKey] = append(byKey[ref.StorageKey], ref)
+	}
+
+	for _, key := range keys {
+		// Ссылка из журнала - операция в процессе: файла может еще или уже не быть.
+		// Проверяются только ссылки документов и версий
+		var keyRefs []models.StorageRef
+		for _, ref := range byKey[key] {
+			if ref.DocumentID != 0 {
+				keyRefs = append(keyRefs, ref)
+			}
+		}
+		if len(keyRefs) == 0 {
+			continue
+		}
+		report.Checked++
+		info, ok := blobs[key]
+		if !ok {
+			report.Missing = append(report.Missing, keyRefs...)
+			continue
+		}
+		// Размер и хеш старых записей еще не заполнены (их заполняет Reconcile)
+		ref := keyRefs[0]
+		if ref.SHA256 == "" {
+			continue
+		}
+		if info.Size != ref.Size {
+			report.Corrupted = append(report.Corrupted, keyRefs...)
+			continue
+		}
+		if !opts.VerifyHashes {
+			continue
+		}
+		_, hash, err := s.hashBlob(ctx, key)
+		if errors.Is(err, storage.ErrNotExist) {
+			report.Missing = append(report.Missing, keyRefs...)
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("cannot hash %s: %v", key, err)
+		}
+		if hash != ref.SHA256 {
+			report.Corrupted = append(report.Corrupted, keyRefs...)
+		}
+	}
+
+	cutoff := time.Now().Add(-opts.Grace)
+	for key, info := range blobs {
+		if _, ok := byKey[key]; !ok && info.ModTime.Before(cutoff) {
+			report.Orphaned = append(report.Orphaned, key)
+		}
+	}
+	sort.Strings(report.Orphaned)
+
+	for _, key := range report.Orphaned {
+		info := blobs[key]
+		switch opts.Orphans {
+		case OrphanQuarantine:
+			if err := s.quarantine(ctx, info); err != nil {
+				return report, fmt.Errorf("cannot quarantine %s: %v", key, err)
+			}
+			report.Quarantined = append(report.Quarantined, key)
+		case OrphanDelete:
+			if err := s.blobs.Delete(ctx, key); err != nil {
+				return report, fmt.Errorf("cannot delete %s: %v", key, err)
+			}
+			report.Deleted = append(report.Deleted, key)
+		}
+	}
+	return report, nil
+}
+
+// listBlobs - все объекты хранилища, кроме файлов в карантине
+func (s *RepairService) listBlobs(ctx context.Context) (map[string]storage.ObjectInfo, error) {
+	blobs := make(map[string]storage.ObjectInfo)
+	err := s.blobs.List(ctx, "", func(info storage.ObjectInfo) error {
+		if !strings.HasPrefix(info.Key, quarantinePrefix) {
+			blobs[info.Key] = info
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list storage: %v", err)
+	}
+	return blobs, nil
+}
+
+// quarantine переносит файл под quarantinePrefix: копирует и удаляет исходный
+func (s *RepairService) quarantine(ctx context.Context, info storage.ObjectInfo) error {
+	content, _, err := s.blobs.Get(ctx, info.Key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if _, err := s.blobs.Put(ctx, quarantinePrefix+info.Key, content, info.Size, info.ContentType); err != nil {
+		return err
+	}
+	return s.blobs.Delete(ctx, info.Key)
+}
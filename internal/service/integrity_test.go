@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/golang/mock/gomock"
+	"github.com/katenester/doc/internal/models"
+	mock_repository "github.com/katenester/doc/internal/repository/mocks"
+	"github.com/katenester/doc/internal/repository/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepairService_CheckIntegrity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := t.TempDir()
+	blobs, err := local.NewStore(root)
+	require.NoError(t, err)
+
+	old := time.Now().Add(-48 * time.Hour)
+	put := func(key, content string, modTime time.Time) string {
+		_, err := blobs.Put(context.Background(), key, strings.NewReader(content), -1, "text/plain")
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(filepath.Join(root, key), modTime, modTime))
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	okHash := put("ok.txt", "hello", old)
+	put("tampered.txt", "HELLO", old) // Тот же размер, другой хеш
+	put("orphan.txt", "lost", old)
+	put("fresh.txt", "uploading", time.Now()) // Сирота моложе grace
+	put("pending.txt", "journal", old)        // Операция из журнала
+
+	repo := mock_repository.NewMockDocument(ctrl)
+	// Ссылки из журнала (document_id = 0) идут первыми, как в ListStorageRefs
+	repo.EXPECT().ListStorageRefs().Return([]models.StorageRef{
+		{DocumentID: 0, StorageKey: "pending.txt"},
+		{DocumentID: 0, StorageKey: "lost.txt"},
+		{DocumentID: 1, StorageKey: "ok.txt", Size: 5, SHA256: okHash},
+		{DocumentID: 1, Version: 1, StorageKey: "ok.txt", Size: 5, SHA256: okHash},
+		{DocumentID: 2, StorageKey: "tampered.txt", Size: 5, SHA256: okHash},
+		{DocumentID: 3, StorageKey: "missing.txt", Size: 1, SHA256: okHash},
+		{DocumentID: 4, StorageKey: "lost.txt", Size: 1, SHA256: okHash}, // На файл ссылаются и журнал, и документ
+	}, nil).Times(2)
+
+	service := NewRepairService(repo, blobs)
+
+	// Без пересчета хешей подмена с тем же размером не видна, сироты только в отчете
+	report, err := service.CheckIntegrity(context.Background(), IntegrityOptions{Grace: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Checked)
+	assert.Equal(t, []models.StorageRef{
+		{DocumentID: 4, StorageKey: "lost.txt", Size: 1, SHA256: okHash},
+		{DocumentID: 3, StorageKey: "missing.txt", Size: 1, SHA256: okHash},
+	}, report.Missing)
+	assert.Empty(t, report.Corrupted)
+	assert.Equal(t, []string{"orphan.txt"}, report.Orphaned)
+	assert.Empty(t, report.Quarantined)
+
+	report, err = service.CheckIntegrity(context.Background(), IntegrityOptions{
+		VerifyHashes: true,
+		Grace:        24 * time.Hour,
+		Orphans:      OrphanQuarantine,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.StorageRef{{DocumentID: 2, StorageKey: "tampered.txt", Size: 5, SHA256: okHash}}, report.Corrupted)
+	assert.Equal(t, []string{"orphan.txt"}, report.Quarantined)
+	assert.NoFileExists(t, filepath.Join(root, "orphan.txt"))
+	assert.FileExists(t, filepath.Join(root, quarantinePrefix+"orphan.txt"))
+	assert.FileExists(t, filepath.Join(root, "fresh.txt"))
+}
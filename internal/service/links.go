@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository"
+	"io"
+	"time"
+)
+
+// Длина slug ссылки в байтах (до кодирования в base64)
+const slugLength = 16
+
+// Сколько паролей ссылок проверяется одновременно. Проверка argon2id занимает 64 МиБ памяти,
+// а пароли ссылок проверяются без входа в систему - остальные запросы ждут своей очереди
+const maxLinkVerifications = 4
+
+// Параметры новой ссылки на документ
+type ShareLinkOptions struct {
+	Password     string     // Пароль (пустой - без пароля)
+	ExpiresAt    *time.Time // Срок действия (nil - бессрочно)
+	MaxDownloads *int       // Максимум обращений (nil - без ограничения)
+}
+
+var errLinkPassword = fmt.Errorf("%w: share link password is required or incorrect", ErrUnauthorized)
+
+type LinksService struct {
+	repo        repository.ShareLinks
+	docs        DocumentService
+	verifySlots chan struct{}
+}
+
+func NewLinksService(repo repository.ShareLinks, docs DocumentService) *LinksService {
+	return &LinksService{repo: repo, docs: docs, verifySlots: make(chan struct{}, maxLinkVerifications)}
+}
+
+// CreateLink создает ссылку на документ. Ссылки создает только владелец документа
+func (s *LinksService) CreateLink(idUser int, idFile int, opts ShareLinkOptions) (models.ShareLink, error) {
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return models.ShareLink{}, fmt.Errorf("%w: expiry time must be in the future", ErrInvalidParams)
+	}
+	if opts.MaxDownloads != nil && *opts.MaxDownloads < 1 {
+		return models.ShareLink{}, fmt.Errorf("%w: max_downloads must be positive", ErrInvalidParams)
+	}
+	if _, err := s.docs.authorize(idUser, idFile, actionOwn); err != nil {
+		return models.ShareLink{}, err
+	}
+
+	// Срок хранится в UTC: смещение часового пояса клиента не должно сдвигать момент истечения
+	if opts.ExpiresAt != nil {
+		expiresAt := opts.ExpiresAt.UTC()
+		opts.ExpiresAt = &expiresAt
+	}
+
+	raw := make([]byte, slugLength)
+	if _, err := rand.Read(raw); err != nil {
+		return models.ShareLink{}, fmt.Errorf("cannot generate slug: %v", err)
+	}
+	link := models.ShareLink{
+		DocumentID:   idFile,
+		Slug:         base64.RawURLEncoding.EncodeToString(raw),
+		ExpiresAt:    opts.ExpiresAt,
+		MaxDownloads: opts.MaxDownloads,
+	}
+	if opts.Password != "" {
+		hash, err := generatePasswordHash(opts.Password)
+		if err != nil {
+			return models.ShareLink{}, err
+		}
+		link.Password = hash
+	}
+	link, err := s.repo.CreateLink(link)
+	return link, repoError(err, "share link")
+}
+
+// ListLinks возвращает все ссылки документа, включая отозванные и истекшие
+func (s *LinksService) ListLinks(idUser int, idFile int) ([]models.ShareLink, error) {
+	if _, err := s.docs.authorize(idUser, idFile, actionOwn); err != nil {
+		return nil, err
+	}
+	return s.repo.ListLinks(idFile)
+}
+
+func (s *LinksService) RevokeLink(idUser int, idFile int, linkID int) error {
+	if _, err := s.docs.authorize(idUser, idFile, actionOwn); err != nil {
+		return err
+	}
+	return repoError(s.repo.RevokeLink(idFile, linkID), "share link")
+}
+
+// OpenLink проверяет ссылку и пароль, учитывает обращение и возвращает документ
+// и поток его содержимого (nil для документа без файла).
+// Обращение учитывается, только если download(doc) = true: продолжение загрузки или ответ
+// из кеша клиента не расходует лимит ссылки
+func (s *LinksService) OpenLink(slug string, password string, download func(doc models.Document) bool) (io.ReadSeekCloser, models.Document, error) {
+	link, err := s.repo.GetLinkBySlug(slug)
+	if err != nil {
+		return nil, models.Document{}, repoError(err, "share link")
+	}
+	if link.RevokedAt != nil || (link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now())) {
+		return nil, models.Document{}, fmt.Errorf("%w: share link has expired", ErrGone)
+	}
+	if link.HasPassword() {
+		if err := s.verifyLinkPassword(password, link.Password); err != nil {
+			return nil, models.Document{}, err
+		}
+	}
+
+	doc, err := s.docs.repo.GetInfo(link.DocumentID)
+	if err != nil {
+		return nil, models.Document{}, repoError(err, "document")
+	}
+	if doc.DeletedAt != nil {
+		return nil, models.Document{}, fmt.Errorf("%w: document has been deleted", ErrGone)
+	}
+
+	// Счетчик увеличивается только при успешном обращении; лимит проверяется атомарно
+	if download(doc) {
+		ok, err := s.repo.CountDownload(link.ID)
+		if err != nil {
+			return nil, models.Document{}, err
+		}
+		if !ok {
+			return nil, models.Document{}, fmt.Errorf("%w: share link is no longer available", ErrGone)
+		}
+	}
+	if !doc.File {
+		return nil, doc, nil
+	}
+	content, err := s.docs.repo.OpenFile(doc.StorageKey)
+	if err != nil {
+		return nil, models.Document{}, err
+	}
+	return content, doc, nil
+}
+
+// verifyLinkPassword - проверка пароля ссылки не более чем в maxLinkVerifications потоков
+func (s *LinksService) verifyLinkPassword(password string, hash string) error {
+	if password == "" {
+		return errLinkPassword
+	}
+	s.verifySlots <- struct{}{}
+	defer func() { <-s.verifySlots }()
+
+	ok, _, err := verifyPassword(password, hash)
+	if err != nil {
+		return fmt.Errorf("cannot verify password: %v", err)
+	}
+	if !ok {
+		return errLinkPassword
+	}
+	return nil
+}
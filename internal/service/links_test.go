@@ -0,0 +1,91 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/katenester/doc/internal/models"
+	mock_repository "github.com/katenester/doc/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLinksService_CreateLink_ExpiryInUTC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	docs := mock_repository.NewMockDocument(ctrl)
+	users := mock_repository.NewMockAuthorization(ctrl)
+	links := mock_repository.NewMockShareLinks(ctrl)
+	docs.EXPECT().GetInfo(docID).Return(models.Document{ID: docID, OwnerID: ownerID}, nil)
+	users.EXPECT().GetUserById(ownerID).Return(owner, nil)
+
+	// 12:00 по Москве - это 09:00 UTC
+	moscow := time.FixedZone("MSK", 3*60*60)
+	expiresAt := time.Date(2099, 10, 18, 12, 0, 0, 0, moscow)
+	links.EXPECT().CreateLink(gomock.Any()).DoAndReturn(func(link models.ShareLink) (models.ShareLink, error) {
+		require.NotNil(t, link.ExpiresAt)
+		assert.Equal(t, time.UTC, link.ExpiresAt.Location())
+		assert.Equal(t, time.Date(2099, 10, 18, 9, 0, 0, 0, time.UTC), *link.ExpiresAt)
+		return link, nil
+	})
+
+	service := NewLinksService(links, *NewDocumentService(docs, users, 0))
+	link, err := service.CreateLink(ownerID, docID, ShareLinkOptions{ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	assert.True(t, link.ExpiresAt.Equal(expiresAt))
+	assert.Equal(t, 12, expiresAt.Hour(), "options of the caller must not be modified")
+}
+
+func TestLinksService_OpenLink_CountsDownloads(t *testing.T) {
+	const linkID = 5
+	tests := []struct {
+		name     string
+		download bool
+		counted  bool
+		wantErr  error
+	}{
+		{name: "full download is counted", download: true, counted: true},
+		{name: "continuation is not counted", download: false},
+		{name: "exhausted link", download: true, counted: false, wantErr: ErrGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			docs := mock_repository.NewMockDocument(ctrl)
+			links := mock_repository.NewMockShareLinks(ctrl)
+			links.EXPECT().GetLinkBySlug("abc").Return(models.ShareLink{ID: linkID, DocumentID: docID}, nil)
+			docs.EXPECT().GetInfo(docID).Return(models.Document{ID: docID, OwnerID: ownerID}, nil)
+			if tt.download {
+				links.EXPECT().CountDownload(linkID).Return(tt.counted, nil)
+			} else {
+				links.EXPECT().CountDownload(gomock.Any()).Times(0)
+			}
+
+			service := NewLinksService(links, *NewDocumentService(docs, mock_repository.NewMockAuthorization(ctrl), 0))
+			_, doc, err := service.OpenLink("abc", "", func(models.Document) bool { return tt.download })
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, docID, doc.ID)
+		})
+	}
+}
+
+func TestLinksService_OpenLink_EmptyPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, err := generatePasswordHash("secret")
+	require.NoError(t, err)
+	links := mock_repository.NewMockShareLinks(ctrl)
+	links.EXPECT().GetLinkBySlug("abc").Return(models.ShareLink{ID: 5, DocumentID: docID, Password: hash}, nil)
+
+	service := NewLinksService(links, DocumentService{})
+	_, _, err = service.OpenLink("abc", "", func(models.Document) bool { return true })
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Хеши паролей хранятся в формате PHC:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<соль base64>$<хеш base64>
+//
+// Параметры и соль записаны в самом хеше, поэтому при их повышении старые хеши продолжают проверяться,
+// а при следующем входе пересчитываются с новыми параметрами.
+// Хеши без префикса "$" - устаревший формат SHA-1 со статической солью.
+
+// Параметры argon2id для новых хешей
+type argon2Params struct {
+	memory      uint32 // Память в КиБ
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+var currentArgon2Params = argon2Params{
+	memory:      64 * 1024,
+	iterations:  3,
+	parallelism: 2,
+	saltLength:  16,
+	keyLength:   32,
+}
+
+// Соль устаревшего формата SHA-1
+const legacySalt = "sfsgGhJjJJHgFRdehYgu"
+
+var errInvalidHash = errors.New("invalid password hash format")
+
+func generatePasswordHash(password string) (string, error) {
+	p := currentArgon2Params
+	salt := make([]byte, p.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyPassword проверяет пароль по хешу. needsRehash = true, если хеш устаревшего формата
+// или посчитан с параметрами слабее текущих
+func verifyPassword(password, encoded string) (ok bool, needsRehash bool, err error) {
+	if !strings.HasPrefix(encoded, "$") {
+		return verifyLegacyPassword(password, encoded), true, nil
+	}
+
+	p, salt, key, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	actual := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false, false, nil
+	}
+	current := currentArgon2Params
+	needsRehash = p.memory < current.memory || p.iterations < current.iterations ||
+		p.parallelism < current.parallelism || uint32(len(salt)) < current.saltLength || uint32(len(key)) < current.keyLength
+	return true, needsRehash, nil
+}
+
+func decodeArgon2Hash(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errInvalidHash
+	}
+	return p, salt, key, nil
+}
+
+// Устаревший формат: hex(соль) + hex(sha1(пароль)) - соль дописывалась к дайджесту, а не хешировалась
+func verifyLegacyPassword(password, encoded string) bool {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+	expected := fmt.Sprintf("%x", hash.Sum([]byte(legacySalt)))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(encoded)) == 1
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/katenester/doc/internal/models"
+	mock_repository "github.com/katenester/doc/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"testing"
+	"time"
+)
+
+const testPassword = "qwerty123"
+
+// Хеш testPassword, посчитанный исходной generatePasswordHash (SHA-1 со статической солью)
+const legacyHash = "7366736747684a6a4a4a486746526465685967755cec175b165e3d5e62c9e13ce848ef6feac81bff"
+
+// weakArgon2Hash - хеш в формате PHC с параметрами слабее текущих
+func weakArgon2Hash(password string) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, 1, 8*1024, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 8*1024, 1, 1,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestVerifyPassword(t *testing.T) {
+	current, err := generatePasswordHash(testPassword)
+	require.NoError(t, err)
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	key := base64.RawStdEncoding.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		name        string
+		password    string
+		encoded     string
+		ok          bool
+		needsRehash bool
+		err         error
+	}{
+		{name: "legacy hash", password: testPassword, encoded: legacyHash, ok: true, needsRehash: true},
+		{name: "legacy hash, wrong password", password: "qwerty124", encoded: legacyHash, ok: false, needsRehash: true},
+		{name: "current params", password: testPassword, encoded: current, ok: true},
+		{name: "current params, wrong password", password: "qwerty124", encoded: current},
+		{name: "weak params", password: testPassword, encoded: weakArgon2Hash(testPassword), ok: true, needsRehash: true},
+		{name: "weak params, wrong password", password: "qwerty124", encoded: weakArgon2Hash(testPassword)},
+		{name: "missing parts", password: testPassword, encoded: "$argon2id$v=19$m=65536,t=3,p=2$" + salt, err: errInvalidHash},
+		{name: "other algorithm", password: testPassword, encoded: "$argon2i$v=19$m=65536,t=3,p=2$" + salt + "$" + key, err: errInvalidHash},
+		{name: "unknown version", password: testPassword, encoded: "$argon2id$v=16$m=65536,t=3,p=2$" + salt + "$" + key, err: errInvalidHash},
+		{name: "malformed params", password: testPassword, encoded: "$argon2id$v=19$m=65536;t=3$" + salt + "$" + key, err: errInvalidHash},
+		{name: "malformed salt", password: testPassword, encoded: "$argon2id$v=19$m=65536,t=3,p=2$!!!$" + key, err: errInvalidHash},
+		{name: "empty key", password: testPassword, encoded: "$argon2id$v=19$m=65536,t=3,p=2$" + salt + "$", err: errInvalidHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := verifyPassword(tt.password, tt.encoded)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.needsRehash, needsRehash)
+			}
+		})
+	}
+}
+
+func TestAuthService_GetUser_Rehash(t *testing.T) {
+	current, err := generatePasswordHash(testPassword)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		rehash   bool
+		wantErr  bool
+	}{
+		{name: "legacy hash is rehashed", stored: legacyHash, password: testPassword, rehash: true},
+		{name: "weak hash is rehashed", stored: weakArgon2Hash(testPassword), password: testPassword, rehash: true},
+		{name: "current hash is kept", stored: current, password: testPassword},
+		{name: "wrong password is not rehashed", stored: legacyHash, password: "qwerty124", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock_repository.NewMockAuthorization(ctrl)
+			repo.EXPECT().GetUserByLogin("alice").Return(models.User{ID: ownerID, Login: "alice", Password: tt.stored}, nil)
+			if tt.rehash {
+				repo.EXPECT().UpdatePasswordHash(ownerID, gomock.Any()).DoAndReturn(func(_ int, hash string) error {
+					ok, needsRehash, err := verifyPassword(tt.password, hash)
+					require.NoError(t, err)
+					assert.True(t, ok)
+					assert.False(t, needsRehash)
+					return nil
+				})
+			} else {
+				repo.EXPECT().UpdatePasswordHash(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			service := NewAuthService(repo, time.Hour, nil)
+			id, err := service.GetUser(models.User{Login: "alice", Password: tt.password})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errInvalidCredentials)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, ownerID, id)
+		})
+	}
+}
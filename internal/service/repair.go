@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository"
+	"github.com/katenester/doc/internal/repository/storage"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// RepairReport - результат сверки таблицы documents с хранилищем
+type RepairReport struct {
+	Checked  int      // Проверено документов с файлом
+	Adopted  []int    // Документы, которым найден и присвоен файл
+	Updated  []int    // Документы, у которых исправлены размер или хеш
+	Missing  []int    // Документы, файл которых не найден
+	Orphaned []string // Файлы в хранилище, на которые не ссылается ни один документ
+}
+
+type RepairService struct {
+	repo  repository.Document
+	blobs storage.BlobStore
+}
+
+func NewRepairService(repo repository.Document, blobs storage.BlobStore) *RepairService {
+	return &RepairService{repo: repo, blobs: blobs}
+}
+
+// Reconcile сверяет документы с содержимым хранилища.
+// Документам без storage_key ищется файл: сначала по имени документа, затем среди файлов без владельца
+// с тем же расширением, созданных не дальше window от created_at (только если кандидат единственный).
+// Для найденных файлов пересчитываются size_bytes и sha256. При dryRun база не изменяется
+func (s *RepairService) Reconcile(ctx context.Context, window time.Duration, dryRun bool) (RepairReport, error) {
+	var report RepairReport
+
+	docs, err := s.repo.ListFiles()
+	if err != nil {
+		return report, err
+	}
+
+	// Все объекты хранилища
+	blobs, err := s.listBlobs(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	// Объекты, на которые уже ссылаются документы, версии или журнал
+	refs, err := s.repo.ListStorageRefs()
+	if err != nil {
+		return report, err
+	}
+	referenced := make(map[string]bool)
+	for _, ref := range refs {
+		referenced[ref.StorageKey] = true
+	}
+
+	for _, doc := range docs {
+		report.Checked++
+
+		key := doc.StorageKey
+		if key == "" {
+			key = s.findLegacyBlob(doc, blobs, referenced, window)
+			if key == "" {
+				report.Missing = append(report.Missing, doc.ID)
+				continue
+			}
+			referenced[key] = true
+			report.Adopted = append(report.Adopted, doc.ID)
+		} else if _, ok := blobs[key]; !ok {
+			report.Missing = append(report.Missing, doc.ID)
+			continue
+		}
+
+		// Пересчитываем размер и хеш, если они не заполнены или не совпадают с хранилищем
+		if key == doc.StorageKey && doc.SHA256 != "" && doc.Size == blobs[key].Size {
+			continue
+		}
+		size, hash, err := s.hashBlob(ctx, key)
+		if err != nil {
+			return report, fmt.Errorf("cannot hash %s: %v", key, err)
+		}
+		if key == doc.StorageKey {
+			report.Updated = append(report.Updated, doc.ID)
+		}
+		if dryRun {
+			continue
+		}
+		if err := s.repo.SetStorage(doc.ID, key, size, hash); err != nil {
+			return report, err
+		}
+	}
+
+	for key := range blobs {
+		if !referenced[key] {
+			report.Orphaned = append(report.Orphaned, key)
+		}
+	}
+
+	return report, nil
+}
+
+// findLegacyBlob ищет файл для документа, созданного до появления storage_key
+func (s *RepairService) findLegacyBlob(doc models.Document, blobs map[string]storage.ObjectInfo, referenced map[string]bool, window time.Duration) string {
+	// Старая версия GetFile искала файл по имени документа
+	if _, ok := blobs[doc.Name]; ok && !referenced[doc.Name] {
+		return doc.Name
+	}
+
+	// Старая версия Create сохраняла файл как uuid + расширение исходного имени
+	var candidate string
+	for key, info := range blobs {
+		if referenced[key] || filepath.Ext(key) != filepath.Ext(doc.Name) {
+			continue
+		}
+		diff := info.ModTime.Sub(doc.CreatedAt)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > window {
+			continue
+		}
+		if candidate != "" {
+			// Несколько подходящих файлов - не угадываем
+			return ""
+		}
+		candidate = key
+	}
+	return candidate
+}
+
+func (s *RepairService) hashBlob(ctx context.Context, key string) (int64, string, error) {
+	content, _, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return 0, "", fmt.Errorf("file disappeared during repair")
+		}
+		return 0, "", err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -3,7 +3,8 @@ package service
 import (
 	"github.com/katenester/doc/internal/models"
 	"github.com/katenester/doc/internal/repository"
-	"mime/multipart"
+	"io"
+	"time"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
@@ -11,26 +12,92 @@ import (
 type Authorization interface {
 	CreateUser(user models.User) error
 	GetUser(user models.User) (int, error)
+	GenerateToken(user models.User, client models.SessionClient) (string, error)
+	AuthorizeAdmin(token string) (string, error)
+	GetUserByLogin(login string) (models.User, error)
 	GetUserId(token string) (int, error)
-	SaveToken(userID int, token string) error
+	SaveToken(userID int, token string, client models.SessionClient) error
 	DeleteToken(token string) error
+	ChangePassword(userID int, token string, oldPassword string, newPassword string) error
+	ListSessions(userID int, token string) ([]models.Session, error)
+	RevokeSession(userID int, sessionID int) error
+}
+
+// Users - управление пользователями (только для администраторов)
+type Users interface {
+	ListUsers(filter models.UserFilter) ([]models.User, string, error)
+	LookupUser(login string) (models.User, error)
+	SetDisabled(login string, disabled bool) error
+	Logout(login string) (int64, error)
+	ResetPassword(login string, password string) error
+	DeleteUser(login string, transferTo string) error
 }
 
 type Document interface {
-	Create(fileHeader *multipart.FileHeader, doc models.Document, users []models.User) error
-	GetFile(idUser int, idFile int) (*multipart.FileHeader, models.Document, error)
-	GetAllFile(idUser int) ([]*multipart.FileHeader, []models.Document, error)
+	Create(doc models.Document, content io.Reader, users []models.User) (int, error)
+	GetInfo(idUser int, idFile int) (models.Document, error)
+	GetFile(idUser int, idFile int) (io.ReadSeekCloser, models.Document, error)
+	GetAllFile(filter models.DocumentFilter) ([]models.Document, *models.DocumentCursor, error)
 	DeleteFile(idUser int, idFile int) error
+	UpdateInfo(idUser int, idFile int, patch DocumentPatch, ifMatch string) (models.Document, error)
+	ReplaceContent(idUser int, idFile int, content io.Reader, mime string, ifMatch string) (models.Document, error)
+	GetGrants(idUser int, idFile int) (models.Document, []models.DocumentGrant, error)
+	AddGrant(idUser int, idFile int, login string, level models.GrantLevel) (models.DocumentGrant, error)
+	RemoveGrant(idUser int, idFile int, login string) error
+	SetPublic(idUser int, idFile int, public bool) error
+	TransferOwner(idUser int, idFile int, login string) error
+	GetGroupGrants(idUser int, idFile int) ([]models.DocumentGroupGrant, error)
+	AddGroupGrant(idUser int, idFile int, idGroup int, level models.GrantLevel) (models.DocumentGroupGrant, error)
+	RemoveGroupGrant(idUser int, idFile int, idGroup int) error
+	ListVersions(idUser int, idFile int) ([]models.DocumentVersion, error)
+	GetVersion(idUser int, idFile int, version int) (io.ReadSeekCloser, models.Document, error)
+	RestoreVersion(idUser int, idFile int, version int, ifMatch string) (models.Document, error)
+	ListTrash(idUser int) ([]models.Document, error)
+	RestoreFile(idUser int, idFile int) error
+	PurgeFile(idUser int, idFile int) error
+	PurgeTrash(retention time.Duration) (int, error)
+	RecoverBlobs(grace time.Duration) (int, error)
+	StorageStats() (models.StorageStats, error)
+}
+
+type ShareLinks interface {
+	CreateLink(idUser int, idFile int, opts ShareLinkOptions) (models.ShareLink, error)
+	ListLinks(idUser int, idFile int) ([]models.ShareLink, error)
+	RevokeLink(idUser int, idFile int, linkID int) error
+	OpenLink(slug string, password string, download func(doc models.Document) bool) (io.ReadSeekCloser, models.Document, error)
+}
+
+type Groups interface {
+	CreateGroup(idUser int, name string) (models.Group, error)
+	ListGroups(idUser int) ([]models.Group, error)
+	GetMembers(idUser int, groupID int) ([]models.GroupMember, error)
+	SetMember(idUser int, groupID int, login string, role models.GroupRole) error
+	RemoveMember(idUser int, groupID int, login string) error
+	DeleteGroup(idUser int, groupID int) error
+}
+
+// Config - настройки сервисов
+type Config struct {
+	SessionTTL time.Duration // Время жизни сессии
+	AdminKeys  []AdminKey    // Ключи администратора для регистрации пользователей
+	Versions   int           // Сколько последних версий хранить для документа (0 - без ограничения)
 }
 
 type Service struct {
 	Authorization
+	Users
 	Document
+	Groups
+	ShareLinks
 }
 
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, cfg Config) *Service {
+	documents := NewDocumentService(repos.Document, repos.Authorization, cfg.Versions)
 	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		Document:      NewDocumentService(repos.Document),
+		Authorization: NewAuthService(repos.Authorization, cfg.SessionTTL, cfg.AdminKeys),
+		Users:         NewUsersService(repos.Authorization, repos.Document, repos.Transactor),
+		Document:      documents,
+		Groups:        NewGroupsService(repos.Groups, repos.Authorization),
+		ShareLinks:    NewLinksService(repos.ShareLinks, *documents),
 	}
 }
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"time"
+)
+
+// ListTrash возвращает документы пользователя в корзине
+func (d DocumentService) ListTrash(idUser int) ([]models.Document, error) {
+	return d.repo.ListTrash(idUser)
+}
+
+// RestoreFile возвращает документ из корзины
+func (d DocumentService) RestoreFile(idUser int, idFile int) error {
+	if _, err := d.authorizeTrashed(idUser, idFile, actionOwn); err != nil {
+		return err
+	}
+	return repoError(d.repo.RestoreFile(idFile), "document")
+}
+
+// PurgeFile окончательно удаляет документ из корзины вместе с файлами всех версий
+func (d DocumentService) PurgeFile(idUser int, idFile int) error {
+	if _, err := d.authorizeTrashed(idUser, idFile, actionOwn); err != nil {
+		return err
+	}
+	deleted, err := d.repo.DeleteFile(context.Background(), idFile, 0)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// Документ восстановлен или удален параллельным запросом после проверки прав
+		return fmt.Errorf("%w: document %d is not in trash", ErrNotFound, idFile)
+	}
+	return nil
+}
+
+// PurgeTrash окончательно удаляет документы, пролежавшие в корзине дольше retention.
+// Возвращает число удаленных документов; при ошибке остальные документы будут удалены при следующем запуске
+func (d DocumentService) PurgeTrash(retention time.Duration) (int, error) {
+	ids, err := d.repo.ListExpiredTrash(retention)
+	if err != nil {
+		return 0, err
+	}
+	// Срок перепроверяется при удалении: документ могли восстановить после получения списка
+	purged := 0
+	for _, id := range ids {
+		deleted, err := d.repo.DeleteFile(context.Background(), id, retention)
+		if err != nil {
+			return purged, fmt.Errorf("cannot purge document %d: %w", id, err)
+		}
+		if deleted {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// RecoverBlobs завершает операции с файлами, прерванные сбоем: удаляет файлы, сохранение документа
+// для которых не было зафиксировано, и файлы удаленных документов. Операции моложе grace
+// считаются еще выполняющимися и не трогаются
+func (d DocumentService) RecoverBlobs(grace time.Duration) (int, error) {
+	return d.repo.RecoverBlobs(context.Background(), grace)
+}
+
+// StorageStats возвращает статистику хранилища и экономию от дедупликации содержимого
+func (d DocumentService) StorageStats() (models.StorageStats, error) {
+	return d.repo.StorageStats()
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/katenester/doc/internal/models"
+	mock_repository "github.com/katenester/doc/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDocumentService_Trash(t *testing.T) {
+	deletedAt := time.Now()
+	activeDoc := models.Document{ID: docID, OwnerID: ownerID, Name: "report.pdf"}
+	trashedDoc := models.Document{ID: docID, OwnerID: ownerID, Name: "report.pdf", DeletedAt: &deletedAt}
+
+	tests := []struct {
+		name    string
+		doc     models.Document
+		call    func(d *DocumentService) error
+		expect  func(docs *mock_repository.MockDocument)
+		wantErr error
+	}{
+		{
+			name: "trashed document is hidden",
+			doc:  trashedDoc,
+			call: func(d *DocumentService) error {
+				_, err := d.GetInfo(ownerID, docID)
+				return err
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "restore of active document",
+			doc:     activeDoc,
+			call:    func(d *DocumentService) error { return d.RestoreFile(ownerID, docID) },
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "purge of active document",
+			doc:     activeDoc,
+			call:    func(d *DocumentService) error { return d.PurgeFile(ownerID, docID) },
+			wantErr: ErrNotFound,
+		},
+		{
+			name:   "restore by owner",
+			doc:    trashedDoc,
+			call:   func(d *DocumentService) error { return d.RestoreFile(ownerID, docID) },
+			expect: func(docs *mock_repository.MockDocument) { docs.EXPECT().RestoreFile(docID).Return(nil) },
+		},
+		{
+			name: "purge by owner",
+			doc:  trashedDoc,
+			call: func(d *DocumentService) error { return d.PurgeFile(ownerID, docID) },
+			expect: func(docs *mock_repository.MockDocument) {
+				docs.EXPECT().DeleteFile(gomock.Any(), docID, time.Duration(0)).Return(true, nil)
+			},
+		},
+		{
+			name: "purge of document restored concurrently",
+			doc:  trashedDoc,
+			call: func(d *DocumentService) error { return d.PurgeFile(ownerID, docID) },
+			expect: func(docs *mock_repository.MockDocument) {
+				docs.EXPECT().DeleteFile(gomock.Any(), docID, time.Duration(0)).Return(false, nil)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			docs := mock_repository.NewMockDocument(ctrl)
+			users := mock_repository.NewMockAuthorization(ctrl)
+			docs.EXPECT().GetInfo(docID).Return(tt.doc, nil)
+			users.EXPECT().GetUserById(ownerID).Return(owner, nil).AnyTimes()
+			if tt.expect != nil {
+				tt.expect(docs)
+			}
+
+			err := tt.call(NewDocumentService(docs, users, 0))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDocumentService_PurgeTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	retention := 30 * 24 * time.Hour
+	docs := mock_repository.NewMockDocument(ctrl)
+	docs.EXPECT().ListExpiredTrash(retention).Return([]int{1, 2, 3}, nil)
+	// Документ 2 восстановлен между получением списка и удалением: срок перепроверяется под блокировкой
+	docs.EXPECT().DeleteFile(gomock.Any(), 1, retention).Return(true, nil)
+	docs.EXPECT().DeleteFile(gomock.Any(), 2, retention).Return(false, nil)
+	docs.EXPECT().DeleteFile(gomock.Any(), 3, retention).Return(true, nil)
+
+	purged, err := NewDocumentService(docs, mock_repository.NewMockAuthorization(ctrl), 0).PurgeTrash(retention)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"io"
+	"strings"
+)
+
+// DocumentPatch - изменения метаданных документа. nil - поле не меняется
+type DocumentPatch struct {
+	Name      *string
+	Mime      *string
+	Public    *bool
+	JSONData  *models.JSONData
+	MergeJSON bool // true - JSONData применяется как JSON Merge Patch (RFC 7396), false - заменяет json_data
+}
+
+// UpdateInfo изменяет метаданные документа. ifMatch - значение заголовка If-Match (пустое - без проверки).
+// Имя, MIME-тип и json_data меняет пользователь с правом изменения, публичность - только владелец
+func (d DocumentService) UpdateInfo(idUser int, idFile int, patch DocumentPatch, ifMatch string) (models.Document, error) {
+	if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+		return models.Document{}, fmt.Errorf("%w: document name is required", ErrInvalidParams)
+	}
+	action := actionEdit
+	if patch.Public != nil {
+		action = actionOwn
+	}
+	doc, err := d.authorize(idUser, idFile, action)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if err := checkIfMatch(doc, ifMatch); err != nil {
+		return models.Document{}, err
+	}
+
+	if patch.Name != nil {
+		doc.Name = *patch.Name
+	}
+	if patch.Mime != nil {
+		doc.Mime = *patch.Mime
+	}
+	if patch.Public != nil {
+		doc.Public = *patch.Public
+	}
+	if patch.JSONData != nil {
+		data := *patch.JSONData
+		if patch.MergeJSON {
+			var current models.JSONData
+			if doc.JSONData != nil {
+				current = *doc.JSONData
+			}
+			data = mergePatch(current, data)
+		}
+		doc.JSONData = &data
+	}
+
+	updated, err := d.repo.UpdateInfo(doc)
+	return updated, repoError(err, "document")
+}
+
+// ReplaceContent заменяет содержимое документа, сохраняя его идентификатор и доступы
+func (d DocumentService) ReplaceContent(idUser int, idFile int, content io.Reader, mime string, ifMatch string) (models.Document, error) {
+	doc, err := d.authorize(idUser, idFile, actionEdit)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if err := checkIfMatch(doc, ifMatch); err != nil {
+		return models.Document{}, err
+	}
+	if mime == "" {
+		mime = doc.Mime
+	}
+	updated, err := d.repo.ReplaceContent(context.Background(), doc, content, mime, idUser)
+	if err != nil {
+		return models.Document{}, repoError(err, "document")
+	}
+	d.pruneVersions(idFile)
+	return updated, nil
+}
+
+// checkIfMatch - проверка заголовка If-Match (RFC 9110): подходит "*" или один из сильных ETag документа
+func checkIfMatch(doc models.Document, ifMatch string) error {
+	if ifMatch == "" {
+		return nil
+	}
+	etag := doc.ETag()
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (candidate == etag && etag != "") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: document has been modified (current ETag %s)", ErrPrecondition, etag)
+}
+
+// mergePatch применяет JSON Merge Patch (RFC 7396): null удаляет ключ, объекты сливаются рекурсивно,
+// остальные значения заменяются
+func mergePatch(target models.JSONData, patch models.JSONData) models.JSONData {
+	result := make(models.JSONData, len(target))
+	for k, v := range target {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if patchObject, ok := v.(map[string]interface{}); ok {
+			targetObject, _ := result[k].(map[string]interface{})
+			result[k] = map[string]interface{}(mergePatch(targetObject, patchObject))
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
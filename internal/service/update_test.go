@@ -0,0 +1,67 @@
+package service
+
+import (
+	"github.com/katenester/doc/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target models.JSONData
+		patch  models.JSONData
+		want   models.JSONData
+	}{
+		{"adds key", models.JSONData{"a": "1"}, models.JSONData{"b": "2"}, models.JSONData{"a": "1", "b": "2"}},
+		{"replaces value", models.JSONData{"a": "1"}, models.JSONData{"a": "2"}, models.JSONData{"a": "2"}},
+		{"null deletes key", models.JSONData{"a": "1", "b": "2"}, models.JSONData{"a": nil}, models.JSONData{"b": "2"}},
+		{"empty target", nil, models.JSONData{"a": "1"}, models.JSONData{"a": "1"}},
+		{
+			"merges nested objects",
+			models.JSONData{"meta": map[string]interface{}{"x": 1.0, "y": 2.0}},
+			models.JSONData{"meta": map[string]interface{}{"y": nil, "z": 3.0}},
+			models.JSONData{"meta": map[string]interface{}{"x": 1.0, "z": 3.0}},
+		},
+		{
+			"object replaces scalar",
+			models.JSONData{"meta": "text"},
+			models.JSONData{"meta": map[string]interface{}{"x": 1.0}},
+			models.JSONData{"meta": map[string]interface{}{"x": 1.0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mergePatch(tt.target, tt.patch))
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	doc := models.Document{SHA256: "abc", UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	etag := doc.ETag()
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		wantErr bool
+	}{
+		{"no header", "", false},
+		{"any", "*", false},
+		{"current etag", etag, false},
+		{"one of list", `"old", ` + etag, false},
+		{"stale etag", `"abc-0"`, true},
+		{"weak etag never matches", "W/" + etag, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkIfMatch(doc, tt.ifMatch)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrPrecondition)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/repository"
+)
+
+// Максимальный размер страницы в списке пользователей
+const maxUsersLimit = 100
+
+// UsersService - управление учетными записями пользователей администратором
+type UsersService struct {
+	users repository.Authorization
+	docs  repository.Document
+	tx    repository.Transactor
+}
+
+func NewUsersService(users repository.Authorization, docs repository.Document, tx repository.Transactor) *UsersService {
+	return &UsersService{users: users, docs: docs, tx: tx}
+}
+
+// ListUsers возвращает страницу списка пользователей, отсортированного по логину,
+// и логин, с которого начинается следующая страница (пустой, если страница последняя)
+func (s *UsersService) ListUsers(filter models.UserFilter) ([]models.User, string, error) {
+	if filter.Limit < 1 || filter.Limit > maxUsersLimit {
+		return nil, "", fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidParams, maxUsersLimit)
+	}
+	page := filter
+	page.Limit = filter.Limit + 1
+	users, err := s.users.ListUsers(page)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) <= filter.Limit {
+		return users, "", nil
+	}
+	users = users[:filter.Limit]
+	return users, users[len(users)-1].Login, nil
+}
+
+func (s *UsersService) LookupUser(login string) (models.User, error) {
+	user, err := s.users.GetUserByLogin(login)
+	return user, repoError(err, "user "+login)
+}
+
+// SetDisabled отключает или включает учетную запись. Отключение завершает все сессии пользователя
+func (s *UsersService) SetDisabled(login string, disabled bool) error {
+	user, err := s.LookupUser(login)
+	if err != nil {
+		return err
+	}
+	return s.users.SetDisabled(context.Background(), user.ID, disabled)
+}
+
+// Logout завершает все сессии пользователя и возвращает их количество
+func (s *UsersService) Logout(login string) (int64, error) {
+	user, err := s.LookupUser(login)
+	if err != nil {
+		return 0, err
+	}
+	return s.users.DeleteSessions(user.ID)
+}
+
+// ResetPassword задает пользователю новый пароль и завершает все его сессии
+func (s *UsersService) ResetPassword(login string, password string) error {
+	if !validatePassword(password) {
+		return fmt.Errorf("%w: password does not meet requirements", ErrInvalidParams)
+	}
+	user, err := s.LookupUser(login)
+	if err != nil {
+		return err
+	}
+	hash, err := generatePasswordHash(password)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePasswordHash(user.ID, hash); err != nil {
+		return err
+	}
+	_, err = s.users.DeleteSessions(user.ID)
+	return err
+}
+
+// DeleteUser удаляет пользователя. Его документы передаются пользователю transferTo,
+// а если transferTo пустой - удаляются вместе с файлами. Документы и пользователь удаляются
+// в одной транзакции: файлы удаляются только если удален и пользователь
+func (s *UsersService) DeleteUser(login string, transferTo string) error {
+	user, err := s.LookupUser(login)
+	if err != nil {
+		return err
+	}
+	var target models.User
+	if transferTo != "" {
+		if transferTo == login {
+			return fmt.Errorf("%w: cannot transfer documents to the deleted user", ErrInvalidParams)
+		}
+		if target, err = s.LookupUser(transferTo); err != nil {
+			return err
+		}
+	}
+
+	return s.tx.Within(context.Background(), func(ctx context.Context) error {
+		if transferTo == "" {
+			if err := s.docs.DeleteOwned(ctx, user.ID); err != nil {
+				return err
+			}
+		} else if err := s.docs.TransferOwner(ctx, user.ID, target.ID); err != nil {
+			return err
+		}
+		return s.users.DeleteUser(ctx, user.ID)
+	})
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"github.com/katenester/doc/internal/models"
+	"github.com/sirupsen/logrus"
+	"io"
+)
+
+// ListVersions возвращает версии содержимого документа, новые первыми
+func (d DocumentService) ListVersions(idUser int, idFile int) ([]models.DocumentVersion, error) {
+	if _, err := d.authorize(idUser, idFile, actionRead); err != nil {
+		return nil, err
+	}
+	versions, err := d.repo.ListVersions(idFile)
+	if err != nil {
+		return nil, err
+	}
+	// Любая замена содержимого создает версию, поэтому текущая - последняя
+	if len(versions) > 0 {
+		versions[0].Current = true
+	}
+	return versions, nil
+}
+
+// GetVersion возвращает поток содержимого версии и документ, в котором MIME-тип, размер, хеш
+// и время изменения заменены значениями версии
+func (d DocumentService) GetVersion(idUser int, idFile int, version int) (io.ReadSeekCloser, models.Document, error) {
+	doc, err := d.authorize(idUser, idFile, actionRead)
+	if err != nil {
+		return nil, models.Document{}, err
+	}
+	v, err := d.repo.GetVersion(idFile, version)
+	if err != nil {
+		return nil, models.Document{}, repoError(err, "version")
+	}
+	content, err := d.repo.OpenFile(v.StorageKey)
+	if err != nil {
+		return nil, models.Document{}, err
+	}
+	doc.Mime, doc.Size, doc.SHA256, doc.UpdatedAt = v.Mime, v.Size, v.SHA256, v.CreatedAt
+	return content, doc, nil
+}
+
+// RestoreVersion делает содержимое старой версии текущим. Версия не переписывается:
+// восстановленное содержимое становится новой версией, поэтому история сохраняется
+func (d DocumentService) RestoreVersion(idUser int, idFile int, version int, ifMatch string) (models.Document, error) {
+	doc, err := d.authorize(idUser, idFile, actionEdit)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if err := checkIfMatch(doc, ifMatch); err != nil {
+		return models.Document{}, err
+	}
+	v, err := d.repo.GetVersion(idFile, version)
+	if err != nil {
+		return models.Document{}, repoError(err, "version")
+	}
+	updated, err := d.repo.RestoreVersion(context.Background(), doc, v, idUser)
+	if err != nil {
+		return models.Document{}, repoError(err, "document")
+	}
+	d.pruneVersions(idFile)
+	return updated, nil
+}
+
+// pruneVersions удаляет версии сверх лимита хранения. Ошибка не отменяет уже сохраненное изменение:
+// лишние версии будут удалены при следующей замене содержимого
+func (d DocumentService) pruneVersions(idFile int) {
+	if d.retention <= 0 {
+		return
+	}
+	if err := d.repo.PruneVersions(context.Background(), idFile, d.retention); err != nil {
+		logrus.Warnf("cannot prune versions of document %d: %s", idFile, err.Error())
+	}
+}
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/models"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+const adminCtx = "admin"
+
+// adminIdentity - проверка ключа администратора или сессии администратора для маршрутов /admin
+func (h *Handler) adminIdentity(c *gin.Context) {
+	token, err := requestToken(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	admin, ok := h.authorizeAdmin(c, token)
+	if !ok {
+		return
+	}
+	c.Set(adminCtx, admin)
+}
+
+// audit - запись действия администратора в лог
+func audit(c *gin.Context, action string, login string) {
+	logrus.WithFields(logrus.Fields{
+		"admin":  c.GetString(adminCtx),
+		"ip":     c.ClientIP(),
+		"action": action,
+		"login":  login,
+	}).Info("admin action")
+}
+
+func (h *Handler) listUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+	users, next, err := h.service.Users.ListUsers(models.UserFilter{
+		Limit: limit,
+		After: c.Query("cursor"), // Логин из next_cursor предыдущего ответа
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+	newDataResponse(c, gin.H{
+		"users":       users,
+		"next_cursor": next,
+	})
+}
+
+func (h *Handler) getUser(c *gin.Context) {
+	user, err := h.service.Users.LookupUser(c.Param("login"))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, user)
+}
+
+// setUserDisabled - обработчик отключения (disabled = true) или включения учетной записи
+func (h *Handler) setUserDisabled(disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		login := c.Param("login")
+		if err := h.service.Users.SetDisabled(login, disabled); err != nil {
+			newServiceErrorResponse(c, err)
+			return
+		}
+		action := "enable"
+		if disabled {
+			action = "disable"
+		}
+		audit(c, action, login)
+		newSuccessResponse(c, gin.H{
+			"login":    login,
+			"disabled": disabled,
+		})
+	}
+}
+
+// Принудительное завершение всех сессий пользователя
+func (h *Handler) logoutUser(c *gin.Context) {
+	login := c.Param("login")
+	count, err := h.service.Users.Logout(login)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	audit(c, "logout", login)
+	newSuccessResponse(c, gin.H{
+		"login":    login,
+		"sessions": count,
+	})
+}
+
+func (h *Handler) resetPassword(c *gin.Context) {
+	var req struct {
+		PSWD string `json:"pswd" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	login := c.Param("login")
+	if err := h.service.Users.ResetPassword(login, req.PSWD); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	audit(c, "reset_password", login)
+	newSuccessResponse(c, SuccessResponse{Login: login})
+}
+
+// Удаление пользователя. Нужно явно выбрать, что делать с его документами:
+// передать другому пользователю (?transfer_to=<login>) или удалить (?purge=true)
+func (h *Handler) deleteUser(c *gin.Context) {
+	login := c.Param("login")
+	transferTo := c.Query("transfer_to")
+	purge := c.Query("purge") == "true"
+	if (transferTo == "") == !purge {
+		newErrorResponse(c, http.StatusBadRequest, "Exactly one of transfer_to or purge=true is required")
+		return
+	}
+	if err := h.service.Users.DeleteUser(login, transferTo); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	audit(c, "delete", login)
+	newSuccessResponse(c, gin.H{
+		"login":       login,
+		"transfer_to": transferTo,
+		"purged":      purge,
+	})
+}
+
+// GET /admin/storage - статистика хранилища: объем всех версий, объем уникальных файлов и экономия
+func (h *Handler) storageStats(c *gin.Context) {
+	stats, err := h.service.Document.StorageStats()
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, stats)
+}
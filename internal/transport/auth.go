@@ -1,39 +1,40 @@
 package transport
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Логика регистрации нового пользователя
 func (h *Handler) register(c *gin.Context) {
 	// Структура для запроса
 	var req struct {
-		Token string `json:"token" binding:"required"`
+		Token string `json:"token"`
 		Login string `json:"login" binding:"required"`
 		PSWD  string `json:"pswd" binding:"required"`
+		Admin bool   `json:"admin"`
 	}
 
 	// Получаем данные из запроса
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": ErrorResponse{
-				Code: 400,
-				Text: "Invalid parameters",
-			},
-		})
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
 		return
 	}
 
-	// Проверяем токен администратора
-	if req.Token != "admin_token" { // Можно извлечь токен из конфига
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": ErrorResponse{
-				Code: 401,
-				Text: "Not authorized",
-			},
-		})
+	// Токен администратора: ключ из конфигурации или сессия администратора.
+	// Можно передать в теле или в заголовке Authorization: Bearer
+	token := req.Token
+	if token == "" {
+		token, _ = requestToken(c)
+	}
+	admin, ok := h.authorizeAdmin(c, token)
+	if !ok {
 		return
 	}
 
@@ -41,28 +42,54 @@ func (h *Handler) register(c *gin.Context) {
 	user := models.User{
 		Login:    req.Login,
 		Password: req.PSWD,
+		IsAdmin:  req.Admin,
 	}
 
 	// Сохраняем пользователя в базе
 	err := h.service.Authorization.CreateUser(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": ErrorResponse{
-				Code: 500,
-				Text: "Failed to create user",
-			},
-		})
+		newServiceErrorResponse(c, err)
 		return
 	}
 
+	c.Set(adminCtx, admin)
+	audit(c, "register", req.Login)
+
 	// Отправляем успешный ответ
-	c.JSON(http.StatusOK, gin.H{
-		"response": SuccessResponse{
-			Login: req.Login,
-		},
+	newSuccessResponse(c, SuccessResponse{
+		Login: req.Login,
 	})
 }
 
+// authorizeAdmin - проверка токена администратора с ограничением числа неудачных попыток с одного адреса.
+// Возвращает имя ключа или логин администратора для аудита
+func (h *Handler) authorizeAdmin(c *gin.Context, token string) (string, bool) {
+	ip := c.ClientIP()
+	if wait, blocked := h.adminLimiter.blocked(ip, time.Now()); blocked {
+		logrus.WithField("ip", ip).Warn("admin authentication rate limited")
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		newErrorResponse(c, http.StatusTooManyRequests, "Too many failed attempts")
+		return "", false
+	}
+
+	admin, err := h.service.Authorization.AuthorizeAdmin(token)
+	if err != nil {
+		if errors.Is(err, service.ErrUnauthorized) || errors.Is(err, service.ErrForbidden) {
+			h.adminLimiter.fail(ip, time.Now())
+			logrus.WithFields(logrus.Fields{
+				"ip":    ip,
+				"path":  c.FullPath(),
+				"error": err.Error(),
+			}).Warn("failed admin authentication")
+		}
+		newServiceErrorResponse(c, err)
+		return "", false
+	}
+
+	h.adminLimiter.reset(ip)
+	return admin, true
+}
+
 // Логика аутентификации пользователя (вернуть токен)
 func (h *Handler) signIn(c *gin.Context) {
 	var req struct {
@@ -72,34 +99,26 @@ func (h *Handler) signIn(c *gin.Context) {
 
 	// Получаем данные из запроса
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": ErrorResponse{
-				Code: 400,
-				Text: "Invalid parameters",
-			},
-		})
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
 		return
 	}
 	user := models.User{
 		Login:    req.Login,
 		Password: req.PSWD,
 	}
-	// Получаем пользователя из базы данных по логину
-	token, err := h.service.Authorization.GetUser(user)
+	// Проверяем логин и пароль и открываем сессию
+	token, err := h.service.Authorization.GenerateToken(user, models.SessionClient{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": ErrorResponse{
-				Code: 401,
-				Text: "Invalid login or password",
-			},
-		})
+		newServiceErrorResponse(c, err)
 		return
 	}
 	// Возвращаем токен
-	c.JSON(http.StatusOK, gin.H{
-		"response": token,
+	newSuccessResponse(c, gin.H{
+		"token": token,
 	})
-
 }
 
 type signInInput struct {
@@ -112,31 +131,19 @@ func (h *Handler) signOut(c *gin.Context) {
 	// Получаем токен из параметра URL
 	token := c.Param("token")
 	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": ErrorResponse{
-				Code: 400,
-				Text: "Bad Request: Token is required in URL",
-			},
-		})
+		newErrorResponse(c, http.StatusBadRequest, "Bad Request: Token is required in URL")
 		return
 	}
 
 	// Удаляем сессию из базы данных
 	err := h.service.Authorization.DeleteToken(token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": ErrorResponse{
-				Code: 500,
-				Text: "Failed to log out: " + err.Error(),
-			},
-		})
+		newServiceErrorResponse(c, err)
 		return
 	}
 
 	// Возвращаем успешный ответ
-	c.JSON(http.StatusOK, gin.H{
-		"response": gin.H{
-			token: true, // Токен, который был удален
-		},
+	newSuccessResponse(c, gin.H{
+		token: true, // Токен, который был удален
 	})
 }
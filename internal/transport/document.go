@@ -1,239 +1,474 @@
 package transport
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
+	"io"
+	"mime"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Метаданные загружаемого документа
+type uploadMeta struct {
+	Name   string   `json:"name"`
+	Mime   string   `json:"mime"`
+	Public bool     `json:"public"`
+	Grant  []string `json:"grant"`
+}
+
+// Загрузка документа в multipart/form-data. Части читаются по порядку:
+//   - token - токен сессии, если он не передан в заголовке или строке запроса (только первой частью);
+//   - meta  - JSON с метаданными (name, mime, public, grant);
+//   - json  - JSON с данными документа (необязательно);
+//   - file  - содержимое файла, передается последним и сразу пишется в хранилище.
+//
+// Если части file нет, создается документ без файла
 func (h *Handler) uploadDocument(c *gin.Context) {
-	// Логика загрузки нового документа
-	// Получаем токен из заголовков или параметров
-	token := c.DefaultPostForm("token", "")
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Token is required",
-		})
+	// Документ без файла присылается одним JSON-телом
+	if c.ContentType() == "application/json" {
+		h.uploadJSONDocument(c)
 		return
 	}
 
-	// Получаем информацию о файле
-	file, fileHeader, err := c.Request.FormFile("file")
+	// Часть token уже прочитана middleware
+	reader, err := multipartForm(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("File is required: %s", err),
-		})
+		newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Multipart body is required: %s", err))
 		return
 	}
-	defer file.Close()
 
-	// Получаем метаданные из JSON
-	var meta struct {
-		Name   string   `json:"name"`
-		Mime   string   `json:"mime"`
-		Public bool     `json:"public"`
-		Grant  []string `json:"grant"`
-		Json   JSONData `json:"json"`
+	// Файл пишется в хранилище прямо из тела запроса и не успевает прийти за ReadTimeout сервера,
+	// а ответ отправляется только после загрузки - снимаем оба дедлайна для этого запроса
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		logrus.Warnf("cannot reset read deadline: %s", err.Error())
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logrus.Warnf("cannot reset write deadline: %s", err.Error())
 	}
 
-	if err := c.ShouldBindJSON(&meta); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Invalid JSON: %s", err),
-		})
+	var (
+		meta     *uploadMeta
+		jsonData models.JSONData
+	)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Invalid multipart body: %s", err))
+			return
+		}
+
+		switch part.FormName() {
+		case "meta":
+			meta = &uploadMeta{}
+			if err := json.NewDecoder(io.LimitReader(part, maxFormFieldSize)).Decode(meta); err != nil {
+				newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Invalid meta JSON: %s", err))
+				return
+			}
+		case "json":
+			if err := json.NewDecoder(io.LimitReader(part, maxFormFieldSize)).Decode(&jsonData); err != nil {
+				newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Invalid json part: %s", err))
+				return
+			}
+		case "file":
+			// Содержимое файла уходит в хранилище, не попадая целиком в память
+			if meta == nil {
+				meta = &uploadMeta{}
+			}
+			if meta.Name == "" {
+				meta.Name = part.FileName()
+			}
+			if meta.Mime == "" {
+				meta.Mime = part.Header.Get("Content-Type")
+			}
+			h.createDocument(c, meta, jsonData, part)
+			return
+		}
+	}
+
+	// Файла нет - документ только с данными
+	if meta == nil {
+		newErrorResponse(c, http.StatusBadRequest, "Meta part is required")
+		return
+	}
+	if jsonData == nil {
+		newErrorResponse(c, http.StatusBadRequest, "File or json part is required")
 		return
 	}
+	h.createDocument(c, meta, jsonData, nil)
+}
+
+// Ограничение на размер текстовых частей multipart-запроса
+const maxFormFieldSize = 1 << 20
 
-	// Получаем пользователя по токену
-	userID, err := h.service.Authorization.GetUserId(token)
+// createDocument - проверка метаданных, сохранение документа и ответ клиенту.
+// content == nil для документа без файла
+func (h *Handler) createDocument(c *gin.Context, meta *uploadMeta, jsonData models.JSONData, content io.Reader) {
+	userID, err := getUserId(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid token",
-		})
 		return
 	}
 
+	if content == nil && meta.Mime == "" {
+		meta.Mime = "application/json"
+	}
+
 	// Создаем документ
 	doc := models.Document{
-		OwnerID:  userID,
-		Name:     meta.Name,
-		Mime:     meta.Mime,
-		Public:   meta.Public,
-		JSONData: &meta.Json,
+		OwnerID: userID,
+		Name:    meta.Name,
+		Mime:    meta.Mime,
+		Public:  meta.Public,
+	}
+	if jsonData != nil {
+		doc.JSONData = &jsonData
+	}
+
+	users, ok := h.resolveGrants(c, meta.Grant)
+	if !ok {
+		return
 	}
 
 	// Сохраняем файл и метаданные в базу данных
+	id, err := h.service.Document.Create(doc, content, users)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	// Ответ с данными: по id к документу обращаются дальнейшие запросы
+	data := gin.H{"id": id, "json": doc.JSONData}
+	if content != nil {
+		data["file"] = doc.Name
+	}
+	newDataResponse(c, data)
+}
+
+// Загрузка документа без файла: данные сохраняются в json_data
+func (h *Handler) uploadJSONDocument(c *gin.Context) {
+	var req struct {
+		Meta uploadMeta      `json:"meta"`
+		Json models.JSONData `json:"json" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %s", err))
+		return
+	}
+
+	// Без файла: в хранилище ничего не пишется
+	h.createDocument(c, &req.Meta, req.Json, nil)
+}
+
+// resolveGrants - пользователи, которым выдается доступ к документу, по логинам
+func (h *Handler) resolveGrants(c *gin.Context, logins []string) ([]models.User, bool) {
 	users := []models.User{}
-	for _, login := range meta.Grant {
+	for _, login := range logins {
 		// Получаем пользователей по логину
-		user, err := h.service.Authorization.GetUser(login)
+		user, err := h.service.Authorization.GetUserByLogin(login)
+		if errors.Is(err, service.ErrNotFound) {
+			// Неизвестный логин в списке доступа - ошибка в параметрах загрузки
+			newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("User %s not found", login))
+			return nil, false
+		}
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("User %s not found", login),
-			})
-			return
+			newServiceErrorResponse(c, err)
+			return nil, false
 		}
 		users = append(users, user)
 	}
-
-	err = h.service.Document.Create(fileHeader, doc, users)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to upload document: %s", err),
-		})
-		return
-	}
-
-	// Ответ с данными
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"json": doc.JSONData,
-			"file": doc.Name,
-		},
-	})
+	return users, true
 }
 
 func (h *Handler) getAllDocuments(c *gin.Context) {
 	// Извлекаем параметры из запроса
-	token := c.DefaultQuery("token", "")
 	login := c.DefaultQuery("login", "")        // Опциональный параметр для фильтрации по логину
 	key := c.DefaultQuery("key", "")            // Опциональный параметр для фильтрации по ключу
 	value := c.DefaultQuery("value", "")        // Значение для фильтра
 	limitParam := c.DefaultQuery("limit", "10") // Ограничение на количество документов
+	cursor := c.DefaultQuery("cursor", "")      // Позиция следующей страницы из предыдущего ответа
 
 	// Парсим параметр limit
 	limit, err := strconv.Atoi(limitParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid limit parameter",
-		})
+		newErrorResponse(c, http.StatusBadRequest, "Invalid limit parameter")
 		return
 	}
 
-	// Проверка токена и получение userID
-	userID, err := h.service.Authorization.GetUserId(token)
+	// Парсим позицию страницы
+	after, err := decodeCursor(cursor)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized: Invalid token",
-		})
+		newErrorResponse(c, http.StatusBadRequest, "Invalid cursor parameter")
+		return
+	}
+
+	// Пользователь, определенный middleware по токену
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	docs, next, err := h.service.Document.GetAllFile(models.DocumentFilter{
+		UserID: userID,
+		Login:  login,
+		Key:    key,
+		Value:  value,
+		Limit:  limit,
+		After:  after,
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
 		return
 	}
-	files, filesInfo, err := h.service.Document.GetAllFile(userID)
 
 	// Формируем результат
-	// Реализовать
+	items := make([]gin.H, 0, len(docs))
+	for _, doc := range docs {
+		grant := doc.Grant
+		if grant == nil {
+			grant = []string{}
+		}
+		items = append(items, gin.H{
+			"id":      doc.ID,
+			"name":    doc.Name,
+			"mime":    doc.Mime,
+			"file":    doc.File,
+			"public":  doc.Public,
+			"created": doc.CreatedAt,
+			"grant":   grant,
+		})
+	}
 
 	// Отправляем успешный ответ
-	c.JSON(http.StatusOK, response)
+	newDataResponse(c, gin.H{
+		"docs":        items,
+		"next_cursor": encodeCursor(next),
+	})
+}
+
+// encodeCursor - непрозрачная строка с позицией следующей страницы (пустая, если страница последняя)
+func encodeCursor(cursor *models.DocumentCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*models.DocumentCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor models.DocumentCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
 }
 
 func (h *Handler) getDocumentByID(c *gin.Context) {
 	// Логика получения одного документа по ID
 	// Извлекаем параметры из запроса
-	token := c.DefaultQuery("token", "")
 	docID := c.Param("id") // ID документа из URL
 
-	// Проверка наличия токена
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Token is required",
-		})
+	// Пользователь, определенный middleware по токену
+	userID, err := getUserId(c)
+	if err != nil {
 		return
 	}
 
-	// Получаем ID пользователя по токену
-	userID, err := h.service.Authorization.GetUserId(token)
+	id, err := strconv.Atoi(docID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized: Invalid token",
-		})
+		newErrorResponse(c, http.StatusBadRequest, "Invalid document id")
 		return
 	}
 
 	// Получаем документ из базы данных
-	docInfo, doc, err := h.service.Document.GetFile(userID, docID)
+	content, doc, err := h.service.Document.GetFile(userID, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Document not found",
-		})
+		newServiceErrorResponse(c, err)
 		return
 	}
 
 	// Если документ файл (file = true), то отдаем его содержимое с нужным mime
 	if doc.File {
-		// Прочитаем файл с диска (предполагается, что путь к файлу хранится в базе данных)
-		filePath := fmt.Sprintf("/path/to/documents/%s", doc.Name) // Замените на правильный путь
-		file, err := os.Open(filePath)
+		defer content.Close()
+		serveContent(c, doc, content)
+		return
+	}
+
+	// Если это не файл, возвращаем метаданные документа в формате JSON (с теми же условными запросами)
+	if checkNotModified(c, doc) {
+		return
+	}
+	newDataResponse(c, documentData(doc))
+}
+
+// documentData - данные ответа для документа без файла (и для ответов на изменение документа)
+func documentData(doc models.Document) gin.H {
+	return gin.H{
+		"id":        doc.ID,
+		"name":      doc.Name,
+		"mime":      doc.Mime,
+		"file":      doc.File,
+		"public":    doc.Public,
+		"created":   doc.CreatedAt,
+		"updated":   doc.UpdatedAt,
+		"json_data": doc.JSONData,
+	}
+}
+
+// serveContent - отдает содержимое документа потоком из хранилища.
+// http.ServeContent обрабатывает Range/If-Range (в том числе несколько диапазонов),
+// If-Match/If-None-Match по ETag и If-Modified-Since/If-Unmodified-Since по updated_at
+func serveContent(c *gin.Context, doc models.Document, content io.ReadSeeker) {
+	if etag := doc.ETag(); etag != "" {
+		c.Header("ETag", etag)
+	}
+	if doc.Mime != "" {
+		c.Header("Content-Type", doc.Mime)
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.Name}))
+
+	// Большие файлы не успевают уйти за WriteTimeout сервера - снимаем дедлайн для этого ответа
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logrus.Warnf("cannot reset write deadline: %s", err.Error())
+	}
+
+	http.ServeContent(c.Writer, c.Request, doc.Name, doc.UpdatedAt, content)
+}
+
+// HEAD /api/docs/:id - те же проверки доступа, что и у GET, но только заголовки.
+// Содержимое файла не читается: размер и хеш берутся из метаданных документа
+func (h *Handler) getDocumentByIDHead(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	// У ответа на HEAD нет тела: клиент увидит только статус ошибки
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid document id")
+		return
+	}
+
+	doc, err := h.service.Document.GetInfo(userID, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("X-Document-Id", strconv.Itoa(doc.ID))
+	c.Header("X-Document-Owner", strconv.Itoa(doc.OwnerID))
+	c.Header("X-Document-Public", strconv.FormatBool(doc.Public))
+	c.Header("X-Document-File", strconv.FormatBool(doc.File))
+	c.Header("X-Document-Created", doc.CreatedAt.UTC().Format(time.RFC3339))
+
+	if !doc.File {
+		// Длина совпадает с телом, которое вернул бы GET
+		body, err := json.Marshal(dataResponse{documentData(doc)})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to read file",
-			})
+			newServiceErrorResponse(c, err)
 			return
 		}
-		defer file.Close()
-
-		// Устанавливаем правильный MIME-тип
-		c.Header("Content-Type", doc.Mime)
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", doc.Name))
+		if checkNotModified(c, doc) {
+			return
+		}
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Header("Content-Length", strconv.Itoa(len(body)))
+		c.Status(http.StatusOK)
+		return
+	}
 
-		// Отправляем файл клиенту
-		c.File(filePath)
+	if checkNotModified(c, doc) {
 		return
 	}
 
-	// Если это не файл, возвращаем метаданные документа в формате JSON
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"id":        doc.ID,
-			"name":      doc.Name,
-			"mime":      doc.Mime,
-			"file":      doc.File,
-			"public":    doc.Public,
-			"created":   doc.CreatedAt,
-			"json_data": doc.JSONData,
-		},
-	})
+	if doc.Mime != "" {
+		c.Header("Content-Type", doc.Mime)
+	}
+	c.Header("Content-Length", strconv.FormatInt(doc.Size, 10))
+	c.Header("Accept-Ranges", "bytes")
+	c.Status(http.StatusOK)
 }
-func (h *Handler) getDocumentByIDHead(c *gin.Context) {
+
+// checkNotModified - заголовки ETag и Last-Modified документа; если у клиента актуальная копия,
+// отвечает 304 и возвращает true. Содержимое файлов проверяет http.ServeContent
+func checkNotModified(c *gin.Context, doc models.Document) bool {
+	etag := doc.ETag()
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !doc.UpdatedAt.IsZero() {
+		c.Header("Last-Modified", doc.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if notModified(c.Request, etag, doc.UpdatedAt) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// notModified - проверка If-None-Match/If-Modified-Since по правилам RFC 9110.
+// If-Modified-Since учитывается, только если нет If-None-Match
+func notModified(r *http.Request, etag string, modtime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modtime.IsZero() {
+		return false
+	}
+	return !modtime.Truncate(time.Second).After(ims)
 }
 func (h *Handler) deleteDocument(c *gin.Context) {
-	// Извлекаем токен из параметров запроса
-	token := c.DefaultQuery("token", "")
-	// Извлекаем ID документа из пути (URL) как параметр
-	doc := c.Param("id") // Получаем ID документа из пути /api/docs/<id>
-	docID, _ := strconv.Atoi(doc)
-	// Проверка наличия токена
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Token is required",
-		})
+	// ID документа из пути /api/docs/<id>; некорректный ID - 400
+	docID, ok := documentID(c)
+	if !ok {
 		return
 	}
 
-	// Получаем ID пользователя по токену
-	userID, err := h.service.Authorization.GetUserId(token)
+	// Пользователь, определенный middleware по токену
+	userID, err := getUserId(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized: Invalid token",
-		})
 		return
 	}
 
 	// Вызов сервиса для удаления файла (документа)
 	err = h.service.Document.DeleteFile(userID, docID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete document",
-		})
+		newServiceErrorResponse(c, err)
 		return
 	}
 
 	// Успешный ответ
-	c.JSON(http.StatusOK, gin.H{
-		"response": gin.H{
-			"success": true,
-		},
+	newSuccessResponse(c, gin.H{
+		"success": true,
 	})
 }
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/models"
+	"net/http"
+	"strconv"
+)
+
+// documentID - идентификатор документа из пути запроса
+func documentID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid document id")
+		return 0, false
+	}
+	return id, true
+}
+
+// Список доступов к документу (для окна «Поделиться»)
+func (h *Handler) getGrants(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	doc, grants, err := h.service.Document.GetGrants(userID, docID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if grants == nil {
+		grants = []models.DocumentGrant{}
+	}
+	groups, err := h.service.Document.GetGroupGrants(userID, docID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if groups == nil {
+		groups = []models.DocumentGroupGrant{}
+	}
+	newDataResponse(c, gin.H{
+		"public": doc.Public,
+		"grants": grants,
+		"groups": groups,
+	})
+}
+
+// Выдача доступа к документу пользователю по логину. Уровень по умолчанию - чтение
+func (h *Handler) addGrant(c *gin.Context) {
+	var req struct {
+		Login string            `json:"login" binding:"required"`
+		Level models.GrantLevel `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if req.Level == "" {
+		req.Level = models.GrantRead
+	}
+	grant, err := h.service.Document.AddGrant(userID, docID, req.Login, req.Level)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, grant)
+}
+
+// Отзыв доступа к документу у пользователя
+func (h *Handler) removeGrant(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Document.RemoveGrant(userID, docID, c.Param("login")); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
+
+// Изменение признака публичности документа
+func (h *Handler) setPublic(c *gin.Context) {
+	var req struct {
+		Public *bool `json:"public" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Document.SetPublic(userID, docID, *req.Public); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"public": *req.Public,
+	})
+}
+
+// Передача документа другому пользователю (только владелец)
+func (h *Handler) transferOwner(c *gin.Context) {
+	var req struct {
+		Login string `json:"login" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Document.TransferOwner(userID, docID, req.Login); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"owner": req.Login,
+	})
+}
@@ -0,0 +1,197 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/models"
+	"net/http"
+	"strconv"
+)
+
+// groupID - идентификатор группы из пути запроса
+func groupID(c *gin.Context, param string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid group id")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) createGroup(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	group, err := h.service.Groups.CreateGroup(userID, req.Name)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, group)
+}
+
+// Группы, в которых состоит текущий пользователь
+func (h *Handler) listGroups(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	groups, err := h.service.Groups.ListGroups(userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if groups == nil {
+		groups = []models.Group{}
+	}
+	newDataResponse(c, gin.H{
+		"groups": groups,
+	})
+}
+
+func (h *Handler) deleteGroup(c *gin.Context) {
+	id, ok := groupID(c, "id")
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Groups.DeleteGroup(userID, id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
+
+func (h *Handler) listGroupMembers(c *gin.Context) {
+	id, ok := groupID(c, "id")
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	members, err := h.service.Groups.GetMembers(userID, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if members == nil {
+		members = []models.GroupMember{}
+	}
+	newDataResponse(c, gin.H{
+		"members": members,
+	})
+}
+
+// Добавление участника в группу или изменение его роли. Роль по умолчанию - member
+func (h *Handler) setGroupMember(c *gin.Context) {
+	var req struct {
+		Role models.GroupRole `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.GroupMemberRole
+	}
+	id, ok := groupID(c, "id")
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Groups.SetMember(userID, id, c.Param("login"), req.Role); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"login": c.Param("login"),
+		"role":  req.Role,
+	})
+}
+
+func (h *Handler) removeGroupMember(c *gin.Context) {
+	id, ok := groupID(c, "id")
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Groups.RemoveMember(userID, id, c.Param("login")); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
+
+// Выдача доступа к документу группе
+func (h *Handler) addGroupGrant(c *gin.Context) {
+	var req struct {
+		GroupID int               `json:"group_id" binding:"required"`
+		Level   models.GrantLevel `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	if req.Level == "" {
+		req.Level = models.GrantRead
+	}
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	grant, err := h.service.Document.AddGroupGrant(userID, docID, req.GroupID, req.Level)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, grant)
+}
+
+// Отзыв доступа к документу у группы
+func (h *Handler) removeGroupGrant(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	id, ok := groupID(c, "group")
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Document.RemoveGroupGrant(userID, docID, id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
@@ -3,18 +3,44 @@ package transport
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
+	"time"
 )
 
+// Config - настройки HTTP-обработчиков
+type Config struct {
+	AdminMaxFailures   int           // Допустимое число неудачных попыток ключа администратора
+	AdminFailureWindow time.Duration // Окно, за которое считаются неудачные попытки
+	LinkMaxFailures    int           // Допустимое число неверных паролей ссылок с одного адреса
+	LinkFailureWindow  time.Duration // Окно, за которое считаются неверные пароли ссылок
+	// Адреса и подсети прокси, которым доверяется X-Forwarded-For. Пусто - не доверять никому:
+	// иначе клиент подставляет любой адрес и обходит ограничения числа попыток
+	TrustedProxies []string
+}
+
 type Handler struct {
-	service *service.Service
+	service      *service.Service
+	adminLimiter *failureLimiter
+	linkLimiter  *failureLimiter
+	proxies      []string
 }
 
-func NewHandler(service *service.Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service *service.Service, cfg Config) *Handler {
+	return &Handler{
+		service:      service,
+		adminLimiter: newFailureLimiter(cfg.AdminMaxFailures, cfg.AdminFailureWindow),
+		linkLimiter:  newFailureLimiter(cfg.LinkMaxFailures, cfg.LinkFailureWindow),
+		proxies:      cfg.TrustedProxies,
+	}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	// ClientIP - ключ ограничителей попыток, поэтому заголовкам прокси верим только от настроенных адресов
+	if err := router.SetTrustedProxies(h.proxies); err != nil {
+		logrus.Errorf("invalid trusted proxies, trusting none: %s", err.Error())
+		router.SetTrustedProxies(nil)
+	}
 	// Группа для работы с аутентификацией и регистрацией
 	auth := router.Group("/auth")
 	{
@@ -23,17 +49,82 @@ func (h *Handler) InitRoutes() *gin.Engine {
 		auth.DELETE("/:token", h.signOut)  // Завершение авторизованной сессии
 	}
 
+	// Документы по ссылкам (без входа в систему)
+	router.GET("/s/:slug", h.openShareLink)
+
+	// Администрирование (ключ администратора или сессия администратора)
+	admin := router.Group("/admin", h.adminIdentity)
+	{
+		users := admin.Group("/users")
+		{
+			users.GET("/", h.listUsers)                            // Список пользователей
+			users.GET("/:login", h.getUser)                        // Пользователь по логину
+			users.POST("/:login/disable", h.setUserDisabled(true)) // Отключение учетной записи
+			users.POST("/:login/enable", h.setUserDisabled(false)) // Включение учетной записи
+			users.DELETE("/:login/sessions", h.logoutUser)         // Завершение всех сессий
+			users.PUT("/:login/password", h.resetPassword)         // Сброс пароля
+			users.DELETE("/:login", h.deleteUser)                  // Удаление пользователя
+		}
+		admin.GET("/storage", h.storageStats) // Статистика хранилища и дедупликации
+	}
+
 	// Группа для работы с документами (защищенные маршруты)
-	api := router.Group("/api")
+	api := router.Group("/api", h.userIdentity)
 	{
+		// Учетная запись текущего пользователя
+		me := api.Group("/me")
+		{
+			me.PUT("/password", h.changePassword)       // Смена пароля
+			me.GET("/sessions", h.listSessions)         // Активные сессии
+			me.DELETE("/sessions/:id", h.revokeSession) // Завершение сессии
+		}
+
+		// Группы пользователей
+		groups := api.Group("/groups")
+		{
+			groups.POST("/", h.createGroup)                           // Создание группы
+			groups.GET("/", h.listGroups)                             // Мои группы
+			groups.DELETE("/:id", h.deleteGroup)                      // Удаление группы (владелец)
+			groups.GET("/:id/members", h.listGroupMembers)            // Участники группы
+			groups.PUT("/:id/members/:login", h.setGroupMember)       // Добавление участника или смена роли
+			groups.DELETE("/:id/members/:login", h.removeGroupMember) // Удаление участника
+		}
+
+		// Корзина: удаленные документы владельца до окончательного удаления
+		trash := api.Group("/trash")
+		{
+			trash.GET("/", h.listTrash)                   // Документы в корзине
+			trash.POST("/:id/restore", h.restoreDocument) // Восстановление документа
+			trash.DELETE("/:id", h.purgeDocument)         // Окончательное удаление
+		}
+
 		// Работа с документами
 		docs := api.Group("/docs")
 		{
-			docs.POST("/", h.uploadDocument)         // Загрузка нового документа
-			docs.GET("/", h.getAllDocuments)         // Получение списка документов
-			docs.GET("/:id", h.getDocumentByID)      // Получение одного документа
-			docs.HEAD("/:id", h.getDocumentByIDHead) // HEAD запрос для документа
-			docs.DELETE("/:id", h.deleteDocument)    // Удаление документа
+			docs.POST("/", h.uploadDocument)           // Загрузка нового документа
+			docs.GET("/", h.getAllDocuments)           // Получение списка документов
+			docs.GET("/:id", h.getDocumentByID)        // Получение одного документа
+			docs.HEAD("/:id", h.getDocumentByIDHead)   // HEAD запрос для документа
+			docs.DELETE("/:id", h.deleteDocument)      // Перемещение документа в корзину
+			docs.PATCH("/:id", h.patchDocument)        // Изменение метаданных
+			docs.PUT("/:id/content", h.replaceContent) // Замена содержимого
+
+			// История версий содержимого
+			docs.GET("/:id/versions", h.listVersions)                     // Список версий
+			docs.GET("/:id/versions/:version", h.getVersion)              // Содержимое версии
+			docs.POST("/:id/versions/:version/restore", h.restoreVersion) // Восстановление версии
+
+			// Управление доступом к документу (владелец или доступ уровня reshare)
+			docs.GET("/:id/grants", h.getGrants)                  // Список доступов
+			docs.POST("/:id/grants", h.addGrant)                  // Выдача доступа
+			docs.DELETE("/:id/grants/:login", h.removeGrant)      // Отзыв доступа
+			docs.POST("/:id/groups", h.addGroupGrant)             // Выдача доступа группе
+			docs.DELETE("/:id/groups/:group", h.removeGroupGrant) // Отзыв доступа у группы
+			docs.POST("/:id/links", h.createShareLink)            // Создание ссылки (только владелец)
+			docs.GET("/:id/links", h.listShareLinks)              // Ссылки документа (только владелец)
+			docs.DELETE("/:id/links/:link", h.revokeShareLink)    // Отзыв ссылки (только владелец)
+			docs.PUT("/:id/public", h.setPublic)                  // Публичность документа (только владелец)
+			docs.PUT("/:id/owner", h.transferOwner)               // Передача владения (только владелец)
 		}
 	}
 	return router
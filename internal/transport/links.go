@@ -0,0 +1,187 @@
+package transport
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовок с паролем ссылки (пароль можно передать и параметром password)
+const sharePasswordHeader = "X-Share-Password"
+
+// shareLinkData - данные ссылки в ответе
+func shareLinkData(link models.ShareLink) gin.H {
+	return gin.H{
+		"id":            link.ID,
+		"document_id":   link.DocumentID,
+		"url":           "/s/" + link.Slug,
+		"has_password":  link.HasPassword(),
+		"expires_at":    link.ExpiresAt,
+		"max_downloads": link.MaxDownloads,
+		"downloads":     link.Downloads,
+		"created_at":    link.CreatedAt,
+		"revoked_at":    link.RevokedAt,
+	}
+}
+
+// Создание ссылки на документ (только владелец)
+func (h *Handler) createShareLink(c *gin.Context) {
+	var req struct {
+		Password     string     `json:"password"`
+		ExpiresAt    *time.Time `json:"expires_at"` // RFC3339
+		MaxDownloads *int       `json:"max_downloads"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	link, err := h.service.ShareLinks.CreateLink(userID, docID, service.ShareLinkOptions{
+		Password:     req.Password,
+		ExpiresAt:    req.ExpiresAt,
+		MaxDownloads: req.MaxDownloads,
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, shareLinkData(link))
+}
+
+func (h *Handler) listShareLinks(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	links, err := h.service.ShareLinks.ListLinks(userID, docID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	items := make([]gin.H, 0, len(links))
+	for _, link := range links {
+		items = append(items, shareLinkData(link))
+	}
+	newDataResponse(c, gin.H{
+		"links": items,
+	})
+}
+
+func (h *Handler) revokeShareLink(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	linkID, err := strconv.Atoi(c.Param("link"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid link id")
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.ShareLinks.RevokeLink(userID, docID, linkID); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
+
+// GET /s/:slug - документ по ссылке без входа в систему.
+// Неверные пароли ограничиваются так же, как попытки ключа администратора.
+// Лимит загрузок расходуют только запросы, которые отдают файл с начала (см. fullDownload)
+func (h *Handler) openShareLink(c *gin.Context) {
+	ip := c.ClientIP()
+	if wait, blocked := h.linkLimiter.blocked(ip, time.Now()); blocked {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		newErrorResponse(c, http.StatusTooManyRequests, "Too many failed attempts")
+		return
+	}
+
+	password := c.GetHeader(sharePasswordHeader)
+	if password == "" {
+		password = c.Query("password")
+	}
+	content, doc, err := h.service.ShareLinks.OpenLink(c.Param("slug"), password, func(doc models.Document) bool {
+		return fullDownload(c.Request, doc)
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrUnauthorized) {
+			h.linkLimiter.fail(ip, time.Now())
+			logrus.WithFields(logrus.Fields{
+				"ip":   ip,
+				"slug": c.Param("slug"),
+			}).Warn("failed share link password")
+		}
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	if doc.File {
+		defer content.Close()
+		serveContent(c, doc, content)
+		return
+	}
+	newDataResponse(c, documentData(doc))
+}
+
+// fullDownload - отдаст ли ответ на запрос файл с начала. Не считаются загрузкой ответы 304 и 412
+// (содержимое не отдается) и продолжения загрузки - запросы Range, все диапазоны которых начинаются не с начала файла
+func fullDownload(r *http.Request, doc models.Document) bool {
+	if !doc.File {
+		return true
+	}
+	etag := doc.ETag()
+	if notModified(r, etag, doc.UpdatedAt) || preconditionFailed(r, etag, doc.UpdatedAt) {
+		return false
+	}
+	spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes=")
+	if !ok {
+		return true
+	}
+	for _, rng := range strings.Split(spec, ",") {
+		start, _, _ := strings.Cut(strings.TrimSpace(rng), "-")
+		// Суффиксный диапазон ("-500") может покрыть весь файл
+		if n, err := strconv.ParseInt(start, 10, 64); err != nil || n == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// preconditionFailed - ответит ли http.ServeContent 412 на If-Match или If-Unmodified-Since
+func preconditionFailed(r *http.Request, etag string, modtime time.Time) bool {
+	if im := r.Header.Get("If-Match"); im != "" {
+		for _, candidate := range strings.Split(im, ",") {
+			if candidate = strings.TrimSpace(candidate); candidate == "*" || (etag != "" && candidate == etag) {
+				return false
+			}
+		}
+		return true
+	}
+	ius, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+	if err != nil || modtime.IsZero() {
+		return false
+	}
+	return modtime.Truncate(time.Second).After(ius)
+}
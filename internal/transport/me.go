@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/models"
+	"net/http"
+	"strconv"
+)
+
+// Смена пароля текущего пользователя. Остальные сессии пользователя завершаются
+func (h *Handler) changePassword(c *gin.Context) {
+	var req struct {
+		OldPSWD string `json:"old_pswd" binding:"required"`
+		NewPSWD string `json:"new_pswd" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Authorization.ChangePassword(userID, c.GetString(tokenCtx), req.OldPSWD, req.NewPSWD); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
+
+// Список активных сессий текущего пользователя
+func (h *Handler) listSessions(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	sessions, err := h.service.Authorization.ListSessions(userID, c.GetString(tokenCtx))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+	newDataResponse(c, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// Завершение одной из сессий текущего пользователя
+func (h *Handler) revokeSession(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid session id")
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Authorization.RevokeSession(userID, sessionID); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{
+		"success": true,
+	})
+}
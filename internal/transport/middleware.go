@@ -1,45 +1,132 @@
 package transport
 
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
 const (
 	authorizationHeader = "Authorization"
 	userCtx             = "userId"
+	tokenCtx            = "token"
+	multipartCtx        = "multipartForm"
+	tokenParam          = "token"
 )
 
-//func (h *Handler) userIdentity(c *gin.Context) {
-//	header := c.GetHeader(authorizationHeader)
-//	if header == "" {
-//		newErrorResponse(c, http.StatusUnauthorized, "empty authorization header")
-//		return
-//	}
-//	headerParts := strings.Split(header, " ")
-//	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-//		newErrorResponse(c, http.StatusUnauthorized, "invalid authorization header")
-//		return
-//	}
-//	if len(headerParts[1]) == 0 {
-//		newErrorResponse(c, http.StatusUnauthorized, "token is empty")
-//		return
-//	}
-//	// parse token
-//	userId, err := h.service.ParseToken(headerParts[1])
-//	if err != nil {
-//		newErrorResponse(c, http.StatusUnauthorized, err.Error())
-//		return
-//	}
-//	// Write value id in context (need for access id in another service (for another  handlers))
-//	c.Set(userCtx, userId)
-//}
-//
-//func getUserId(c *gin.Context) (int, error) {
-//	userId, ok := c.Get(userCtx)
-//	if !ok {
-//		newErrorResponse(c, http.StatusInternalServerError, "user id not found")
-//		return 0, errors.New("user id not found")
-//	}
-//	idInt, ok := userId.(int)
-//	if !ok {
-//		newErrorResponse(c, http.StatusInternalServerError, "user id is of invalid type")
-//		return 0, errors.New("user id is of invalid type")
-//	}
-//	return idInt, nil
-//}
+// userIdentity - проверка сессии для защищенных маршрутов.
+// Токен принимается из заголовка Authorization: Bearer <token>, параметра token в строке запроса
+// или поля token формы
+func (h *Handler) userIdentity(c *gin.Context) {
+	token, err := requestToken(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if token == "" {
+		newErrorResponse(c, http.StatusUnauthorized, "token is required")
+		return
+	}
+	userId, err := h.service.Authorization.GetUserId(token)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	// Write value id in context (need for access id in another service (for another  handlers))
+	c.Set(userCtx, userId)
+	c.Set(tokenCtx, token)
+}
+
+// requestToken - токен из заголовка, строки запроса или формы (в этом порядке)
+func requestToken(c *gin.Context) (string, error) {
+	if header := c.GetHeader(authorizationHeader); header != "" {
+		headerParts := strings.Split(header, " ")
+		if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+			return "", errors.New("invalid authorization header")
+		}
+		return headerParts[1], nil
+	}
+	if token := c.Query(tokenParam); token != "" {
+		return token, nil
+	}
+	switch c.ContentType() {
+	case "application/x-www-form-urlencoded":
+		return c.PostForm(tokenParam), nil
+	case "multipart/form-data":
+		return multipartToken(c)
+	}
+	return "", nil
+}
+
+// multipartToken - токен из первой части multipart-формы.
+// Форма не разбирается целиком, чтобы файл можно было передать в хранилище потоком:
+// читается только первая часть, а поток формы сохраняется в контексте для обработчика
+func multipartToken(c *gin.Context) (string, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return "", err
+	}
+	form := &multipartStream{reader: reader}
+	c.Set(multipartCtx, form)
+
+	part, err := reader.NextPart()
+	if err == io.EOF {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if part.FormName() != tokenParam {
+		form.pending = part
+		return "", nil
+	}
+	value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// multipartStream - поток частей multipart-формы, первая часть которого могла быть уже прочитана middleware
+type multipartStream struct {
+	reader  *multipart.Reader
+	pending *multipart.Part
+}
+
+func (m *multipartStream) NextPart() (*multipart.Part, error) {
+	if m.pending != nil {
+		part := m.pending
+		m.pending = nil
+		return part, nil
+	}
+	return m.reader.NextPart()
+}
+
+// multipartForm - поток частей формы запроса (с учетом частей, прочитанных middleware)
+func multipartForm(c *gin.Context) (*multipartStream, error) {
+	if form, ok := c.Get(multipartCtx); ok {
+		return form.(*multipartStream), nil
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	return &multipartStream{reader: reader}, nil
+}
+
+func getUserId(c *gin.Context) (int, error) {
+	userId, ok := c.Get(userCtx)
+	if !ok {
+		newErrorResponse(c, http.StatusInternalServerError, "user id not found")
+		return 0, errors.New("user id not found")
+	}
+	idInt, ok := userId.(int)
+	if !ok {
+		newErrorResponse(c, http.StatusInternalServerError, "user id is of invalid type")
+		return 0, errors.New("user id is of invalid type")
+	}
+	return idInt, nil
+}
@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"sync"
+	"time"
+)
+
+// failureLimiter - ограничение числа неудачных попыток с одного адреса.
+// После maxFailures неудач за window адрес блокируется до конца окна
+type failureLimiter struct {
+	mu          sync.Mutex
+	maxFailures int
+	window      time.Duration
+	attempts    map[string]*failureWindow
+}
+
+type failureWindow struct {
+	failures int
+	resetAt  time.Time
+}
+
+// Значения по умолчанию, если ограничение не задано в конфигурации
+const (
+	defaultMaxFailures   = 5
+	defaultFailureWindow = 15 * time.Minute
+)
+
+func newFailureLimiter(maxFailures int, window time.Duration) *failureLimiter {
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxFailures
+	}
+	if window <= 0 {
+		window = defaultFailureWindow
+	}
+	return &failureLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		attempts:    make(map[string]*failureWindow),
+	}
+}
+
+// blocked - сколько осталось ждать, если адрес заблокирован
+func (l *failureLimiter) blocked(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.attempts[key]
+	if !ok {
+		return 0, false
+	}
+	if !now.Before(w.resetAt) {
+		delete(l.attempts, key)
+		return 0, false
+	}
+	return w.resetAt.Sub(now), w.failures >= l.maxFailures
+}
+
+// fail - учесть неудачную попытку
+func (l *failureLimiter) fail(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Заодно убираем истекшие окна, чтобы карта не росла бесконечно
+	for k, w := range l.attempts {
+		if !now.Before(w.resetAt) {
+			delete(l.attempts, k)
+		}
+	}
+	w, ok := l.attempts[key]
+	if !ok {
+		w = &failureWindow{resetAt: now.Add(l.window)}
+		l.attempts[key] = w
+	}
+	w.failures++
+}
+
+// reset - сбросить счетчик после успешной попытки
+func (l *failureLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+}
@@ -1,21 +1,21 @@
 package transport
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/service"
 	"github.com/sirupsen/logrus"
+	"net/http"
 )
 
-type errorResponse struct {
-	Message string `json:"message"`
-}
-
-func newErrorResponse(c *gin.Context, statusCode int, message string) {
-	logrus.Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{message})
-}
+// Каждый ответ API имеет ровно одну из трех форм:
+//
+//	{"error": {"code": 404, "text": "..."}} - ошибка
+//	{"response": ...}                      - результат действия (регистрация, вход, удаление)
+//	{"data": ...}                          - запрошенные данные (документы, списки)
 
-type statusResponse struct {
-	Status string `json:"status"`
+type errorResponse struct {
+	Error ErrorResponse `json:"error"`
 }
 
 // Структура для ответа
@@ -24,6 +24,57 @@ type ErrorResponse struct {
 	Text string `json:"text"`
 }
 
+type successResponse struct {
+	Response interface{} `json:"response"`
+}
+
+type dataResponse struct {
+	Data interface{} `json:"data"`
+}
+
 type SuccessResponse struct {
 	Login string `json:"login"`
 }
+
+func newErrorResponse(c *gin.Context, statusCode int, message string) {
+	logrus.Error(message)
+	c.AbortWithStatusJSON(statusCode, errorResponse{ErrorResponse{Code: statusCode, Text: message}})
+}
+
+// Соответствие доменных ошибок сервисов HTTP-кодам
+var serviceErrorStatus = []struct {
+	err    error
+	status int
+}{
+	{service.ErrInvalidParams, http.StatusBadRequest},
+	{service.ErrUnauthorized, http.StatusUnauthorized},
+	{service.ErrForbidden, http.StatusForbidden},
+	{service.ErrNotFound, http.StatusNotFound},
+	{service.ErrConflict, http.StatusConflict},
+	{service.ErrGone, http.StatusGone},
+	{service.ErrPrecondition, http.StatusPreconditionFailed},
+}
+
+// newServiceErrorResponse - ответ на ошибку сервиса. Текст доменных ошибок отдается клиенту,
+// остальные ошибки считаются внутренними: клиент получает только код 500, подробности - в лог
+func newServiceErrorResponse(c *gin.Context, err error) {
+	for _, e := range serviceErrorStatus {
+		if errors.Is(err, e.err) {
+			newErrorResponse(c, e.status, err.Error())
+			return
+		}
+	}
+	logrus.Error(err.Error())
+	c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse{ErrorResponse{
+		Code: http.StatusInternalServerError,
+		Text: "internal server error",
+	}})
+}
+
+func newSuccessResponse(c *gin.Context, response interface{}) {
+	c.JSON(http.StatusOK, successResponse{response})
+}
+
+func newDataResponse(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, dataResponse{data})
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/trash - документы пользователя в корзине
+func (h *Handler) listTrash(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	docs, err := h.service.Document.ListTrash(userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, gin.H{"docs": docs})
+}
+
+// POST /api/trash/:id/restore - восстановление документа из корзины
+func (h *Handler) restoreDocument(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Document.RestoreFile(userID, docID); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{"success": true})
+}
+
+// DELETE /api/trash/:id - окончательное удаление документа из корзины
+func (h *Handler) purgeDocument(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+	if err := h.service.Document.PurgeFile(userID, docID); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newSuccessResponse(c, gin.H{"success": true})
+}
@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/katenester/doc/internal/models"
+	"github.com/katenester/doc/internal/service"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// PATCH /api/docs/:id - изменение метаданных документа.
+// json_mode: "merge" (по умолчанию) - json применяется как JSON Merge Patch, "replace" - заменяет json_data.
+// С заголовком If-Match документ изменяется, только если его ETag не изменился
+func (h *Handler) patchDocument(c *gin.Context) {
+	var req struct {
+		Name     *string          `json:"name"`
+		Mime     *string          `json:"mime"`
+		Public   *bool            `json:"public"`
+		Json     *models.JSONData `json:"json"`
+		JsonMode string           `json:"json_mode"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid parameters")
+		return
+	}
+	if req.JsonMode != "" && req.JsonMode != "merge" && req.JsonMode != "replace" {
+		newErrorResponse(c, http.StatusBadRequest, "json_mode must be merge or replace")
+		return
+	}
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	doc, err := h.service.Document.UpdateInfo(userID, docID, service.DocumentPatch{
+		Name:      req.Name,
+		Mime:      req.Mime,
+		Public:    req.Public,
+		JSONData:  req.Json,
+		MergeJSON: req.JsonMode != "replace",
+	}, c.GetHeader("If-Match"))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", doc.ETag())
+	newDataResponse(c, documentData(doc))
+}
+
+// PUT /api/docs/:id/content - замена содержимого документа телом запроса (MIME-тип - из Content-Type).
+// Идентификатор и доступы сохраняются; If-Match - как у PATCH
+func (h *Handler) replaceContent(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	// Большие файлы не успевают прийти за ReadTimeout сервера - снимаем дедлайн для этого запроса
+	if err := http.NewResponseController(c.Writer).SetReadDeadline(time.Time{}); err != nil {
+		logrus.Warnf("cannot reset read deadline: %s", err.Error())
+	}
+
+	doc, err := h.service.Document.ReplaceContent(userID, docID, c.Request.Body, c.ContentType(), c.GetHeader("If-Match"))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", doc.ETag())
+	newDataResponse(c, documentData(doc))
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// versionNumber - номер версии из пути запроса. При ошибке ответ уже отправлен
+func versionNumber(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		newErrorResponse(c, http.StatusBadRequest, "Invalid version number")
+		return 0, false
+	}
+	return version, true
+}
+
+// GET /api/docs/:id/versions - история версий содержимого, новые первыми
+func (h *Handler) listVersions(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	versions, err := h.service.Document.ListVersions(userID, docID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	newDataResponse(c, gin.H{"versions": versions})
+}
+
+// GET /api/docs/:id/versions/:version - содержимое конкретной версии
+func (h *Handler) getVersion(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	version, ok := versionNumber(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	content, doc, err := h.service.Document.GetVersion(userID, docID, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	defer content.Close()
+	serveContent(c, doc, content)
+}
+
+// POST /api/docs/:id/versions/:version/restore - восстановление версии как текущего содержимого.
+// Создается новая версия; If-Match - как у PATCH
+func (h *Handler) restoreVersion(c *gin.Context) {
+	docID, ok := documentID(c)
+	if !ok {
+		return
+	}
+	version, ok := versionNumber(c)
+	if !ok {
+		return
+	}
+	userID, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	doc, err := h.service.Document.RestoreVersion(userID, docID, version, c.GetHeader("If-Match"))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", doc.ETag())
+	newDataResponse(c, documentData(doc))
+}
@@ -0,0 +1,2 @@
+-- Ключ содержимого документа в хранилище файлов (пустой для документов без файла)
+ALTER TABLE documents ADD COLUMN storage_key TEXT NOT NULL DEFAULT '';
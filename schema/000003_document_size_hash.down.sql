@@ -0,0 +1,2 @@
+ALTER TABLE documents DROP COLUMN sha256;
+ALTER TABLE documents DROP COLUMN size_bytes;
@@ -0,0 +1,3 @@
+-- Реальный размер и хеш содержимого документа
+ALTER TABLE documents ADD COLUMN size_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE documents ADD COLUMN sha256 VARCHAR(64) NOT NULL DEFAULT '';
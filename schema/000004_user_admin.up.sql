@@ -0,0 +1,2 @@
+-- Администраторы могут регистрировать пользователей своей сессией
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Отключенный пользователь не может войти, его сессии не принимаются
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- Сведения о клиенте, открывшем сессию (для списка активных сессий пользователя)
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Время выдачи доступа (для списка доступов в интерфейсе)
+ALTER TABLE document_grants ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,3 @@
+-- Уровень доступа: read - чтение, edit - изменение метаданных и содержимого, reshare - еще и выдача доступа другим
+ALTER TABLE document_grants ADD COLUMN level VARCHAR(16) NOT NULL DEFAULT 'read'
+    CHECK (level IN ('read', 'edit', 'reshare'));
@@ -0,0 +1,3 @@
+DROP TABLE document_group_grants;
+DROP TABLE group_members;
+DROP TABLE groups;
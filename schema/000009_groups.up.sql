@@ -0,0 +1,26 @@
+-- Группы пользователей
+CREATE TABLE groups (
+                        id SERIAL PRIMARY KEY,
+                        name VARCHAR(255) NOT NULL UNIQUE,              -- Название группы
+                        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Участники групп. owner - управляет группой и ролями, manager - добавляет и удаляет участников
+CREATE TABLE group_members (
+                               group_id INT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+                               user_id INT NOT NULL REFERENCES users(id),
+                               role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'manager', 'member')),
+                               created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                               PRIMARY KEY (group_id, user_id)
+);
+CREATE INDEX group_members_user_id_idx ON group_members (user_id);
+
+-- Доступ к документам для групп (уровни как в document_grants)
+CREATE TABLE document_group_grants (
+                                       id SERIAL PRIMARY KEY,
+                                       document_id INT NOT NULL REFERENCES documents(id),
+                                       group_id INT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+                                       level VARCHAR(16) NOT NULL DEFAULT 'read' CHECK (level IN ('read', 'edit', 'reshare')),
+                                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                       UNIQUE (document_id, group_id)
+);
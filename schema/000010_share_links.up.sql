@@ -0,0 +1,13 @@
+-- Ссылки для доступа к документу без входа в систему
+CREATE TABLE share_links (
+                             id SERIAL PRIMARY KEY,
+                             document_id INT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+                             slug VARCHAR(64) NOT NULL UNIQUE,            -- Случайная часть адреса /s/<slug>
+                             password_hash TEXT NOT NULL DEFAULT '',      -- Хеш пароля (пустой - без пароля)
+                             expires_at TIMESTAMP,                        -- Срок действия (NULL - бессрочно)
+                             max_downloads INT,                           -- Максимум обращений (NULL - без ограничения)
+                             downloads INT NOT NULL DEFAULT 0,            -- Число обращений
+                             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                             revoked_at TIMESTAMP                         -- Время отзыва ссылки
+);
+CREATE INDEX share_links_document_id_idx ON share_links (document_id);
@@ -0,0 +1,19 @@
+-- Неизменяемые версии содержимого документов. Текущая версия - та, storage_key которой совпадает с documents.storage_key
+CREATE TABLE document_versions (
+                                   id SERIAL PRIMARY KEY,
+                                   document_id INT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+                                   version INT NOT NULL,                                   -- Номер версии (с 1)
+                                   storage_key TEXT NOT NULL,                              -- Ключ содержимого в хранилище файлов
+                                   size_bytes BIGINT NOT NULL DEFAULT 0,
+                                   sha256 VARCHAR(64) NOT NULL DEFAULT '',
+                                   mime VARCHAR(100),
+                                   author_id INT REFERENCES users(id) ON DELETE SET NULL, -- Кто загрузил или восстановил версию
+                                   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                   UNIQUE (document_id, version)
+);
+
+-- Текущее содержимое существующих документов становится их первой версией
+INSERT INTO document_versions (document_id, version, storage_key, size_bytes, sha256, mime, author_id, created_at)
+SELECT id, 1, storage_key, size_bytes, sha256, mime, owner_id, updated_at
+FROM documents
+WHERE file AND storage_key <> '';
@@ -0,0 +1,2 @@
+DROP INDEX documents_deleted_at_idx;
+ALTER TABLE documents DROP COLUMN deleted_at;
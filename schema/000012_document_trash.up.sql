@@ -0,0 +1,4 @@
+-- Время перемещения документа в корзину (NULL - документ не удален). Из корзины документы удаляются окончательно
+-- по истечении срока хранения
+ALTER TABLE documents ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX documents_deleted_at_idx ON documents (deleted_at) WHERE deleted_at IS NOT NULL;
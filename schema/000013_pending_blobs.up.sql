@@ -0,0 +1,9 @@
+-- Журнал незавершенных операций с файлами хранилища (write-ahead).
+-- upload: запись добавляется до записи файла и удаляется в транзакции, сохраняющей документ.
+-- delete: запись добавляется в транзакции, удаляющей документ, и удаляется после удаления файла.
+-- Оставшиеся записи (сбой между шагами) доводит до конца фоновое восстановление
+CREATE TABLE pending_blobs (
+                               storage_key TEXT PRIMARY KEY,
+                               operation VARCHAR(10) NOT NULL CHECK (operation IN ('upload', 'delete')),
+                               created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,23 @@
+-- Хранимое содержимое, адресуемое по sha256: одинаковые файлы хранятся один раз.
+-- refcount - число версий документов (document_versions), ссылающихся на файл; при 0 файл удаляется
+CREATE TABLE blobs (
+                       storage_key TEXT PRIMARY KEY,                               -- Ключ файла в хранилище
+                       sha256 VARCHAR(64) NOT NULL DEFAULT '',                     -- Пустой у старых файлов без хеша
+                       size_bytes BIGINT NOT NULL DEFAULT 0,
+                       refcount INT NOT NULL DEFAULT 0 CHECK (refcount >= 0),
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX blobs_sha256_idx ON blobs (sha256) WHERE sha256 <> '';
+
+-- Существующие файлы. Уже загруженные дубликаты остаются отдельными файлами:
+-- хеш получает только самый ранний из них, новые загрузки ссылаются на него
+INSERT INTO blobs (storage_key, sha256, size_bytes, refcount, created_at)
+SELECT storage_key,
+       CASE WHEN ROW_NUMBER() OVER (PARTITION BY sha256 ORDER BY created_at, storage_key) = 1 THEN sha256 ELSE '' END,
+       size_bytes, refcount, created_at
+FROM (
+         SELECT storage_key, MAX(sha256) AS sha256, MAX(size_bytes) AS size_bytes, COUNT(*) AS refcount,
+                MIN(created_at) AS created_at
+         FROM document_versions
+         GROUP BY storage_key
+     ) v;
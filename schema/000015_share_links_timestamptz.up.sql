@@ -0,0 +1,3 @@
+-- Срок действия ссылки хранится с часовым поясом: TIMESTAMP отбрасывал смещение из RFC3339,
+-- и срок сдвигался на разницу с UTC. Прежние значения записаны в UTC
+ALTER TABLE share_links ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC';
@@ -0,0 +1 @@
+ALTER TABLE groups ADD CONSTRAINT groups_name_key UNIQUE (name);
@@ -0,0 +1,3 @@
+-- Название группы не обязано быть уникальным: группы создают обычные пользователи,
+-- и глобальная уникальность раскрывала чужие названия и мешала создавать свои
+ALTER TABLE groups DROP CONSTRAINT groups_name_key;